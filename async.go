@@ -0,0 +1,200 @@
+package giant
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultInterval    time.Duration = time.Second
+	defaultMaxInterval time.Duration = 30 * time.Second
+)
+
+// Poll represents options for SendAsync.
+type Poll struct {
+	// Done reports whether the status body is in a terminal state,
+	// returning an error for failed states, and is required.
+	Done func(status []byte) (done bool, err error)
+	// Result optionally returns the path of the result from the final status body,
+	// when nil or blank the final status body is decoded as the result.
+	// It is resolved against the status path and must be under BaseUri, as with Location.
+	Result func(status []byte) (path string, err error)
+	// StatusPath optionally returns the path of the status resource from the submit body,
+	// used when the submit response does not include a Location header.
+	// It is resolved against the submit path and must be under BaseUri, as with Location.
+	StatusPath func(submit []byte) (path string, err error)
+	// Interval is the initial wait between polls, doubling up to MaxInterval.
+	Interval time.Duration
+	// MaxInterval caps the wait between polls.
+	MaxInterval time.Duration
+	// MaxPolls limits the number of polls when non-zero.
+	MaxPolls int
+}
+
+// SendAsync submits a long-running job and polls its status until done, decoding the result into rcvObj.
+// A submit response other than 202 Accepted is taken as the result straight away.
+// Waits between polls honor Retry-After when sent.
+func (giant *Giant) SendAsync(ctx context.Context, method, path string, sndObj, rcvObj any, poll Poll) (err error) {
+
+	if poll.Done == nil {
+		err = errors.Errorf("poll for %s has no done func", path)
+		return
+	}
+
+	codec := giant.codec(ctx)
+	ctype := codec.ContentType()
+	accept := giant.accept(codec)
+
+	sndData, err := encode(codec, sndObj)
+	if err != nil {
+		return
+	}
+
+	response, body, err := giant.sendData(ctx, method, path, bytes.NewBuffer(sndData), ctype, accept)
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != http.StatusAccepted {
+		if len(body) > 0 {
+			err = giant.decodeResponse(codec, response, body, rcvObj)
+		}
+		return
+	}
+
+	statusPath, err := poll.statusPath(giant.BaseUri, path, response, body)
+	if err != nil {
+		return
+	}
+
+	interval := poll.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	maxInterval := poll.MaxInterval
+	if maxInterval == 0 {
+		maxInterval = defaultMaxInterval
+	}
+
+	for polls := 0; ; polls++ {
+
+		if poll.MaxPolls > 0 && polls >= poll.MaxPolls {
+			err = errors.Errorf("job at %s not done after %d polls", statusPath, polls)
+			return
+		}
+
+		err = wait(ctx, retryAfter(response.Header, interval))
+		if err != nil {
+			err = errors.Wrapf(err, "stopped polling job at %s", statusPath)
+			return
+		}
+		interval = min(interval*2, maxInterval)
+
+		response, body, err = giant.sendData(ctx, "GET", statusPath, nil, ctype, accept)
+		if err != nil {
+			return
+		}
+
+		var done bool
+		done, err = poll.Done(body)
+		if err != nil {
+			err = errors.Wrapf(err, "job at %s failed", statusPath)
+			return
+		}
+		if done {
+			break
+		}
+	}
+
+	if poll.Result != nil {
+		var resultPath string
+		resultPath, err = poll.Result(body)
+		if err != nil {
+			return
+		}
+
+		if resultPath != "" {
+			resultPath, err = resolvePath(giant.BaseUri, statusPath, resultPath)
+			if err != nil {
+				return
+			}
+
+			response, body, err = giant.sendData(ctx, "GET", resultPath, nil, ctype, accept)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	err = giant.decodeResponse(codec, response, body, rcvObj)
+	return
+}
+
+// unexported
+
+func (poll Poll) statusPath(baseUri, path string, response *http.Response, body []byte) (statusPath string, err error) {
+
+	location := response.Header.Get("Location")
+	if location != "" {
+		statusPath, err = resolvePath(baseUri, path, location)
+		return
+	}
+
+	if poll.StatusPath == nil {
+		err = errors.Errorf("accepted job at %s has no location", path)
+		return
+	}
+
+	ref, err := poll.StatusPath(body)
+	if err != nil {
+		return
+	}
+	if ref == "" {
+		err = errors.Errorf("accepted job at %s has no status path", path)
+		return
+	}
+
+	statusPath, err = resolvePath(baseUri, path, ref)
+	return
+}
+
+// retryAfter returns the wait specified by a Retry-After header in seconds or http-date form,
+// falling back to the interval given.
+
+func retryAfter(header http.Header, interval time.Duration) time.Duration {
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return interval
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return interval
+}
+
+func wait(ctx context.Context, delay time.Duration) error {
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,364 @@
+package giant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SendAsync", func() {
+
+	var (
+		srv    *httptest.Server
+		gnt    *Giant
+		ctx    context.Context
+		poll   Poll
+		polls  int
+		rcvObj *foo
+		err    error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		polls = 0
+		rcvObj = &foo{}
+
+		poll = Poll{
+			Done: func(status []byte) (done bool, err error) {
+				var job struct {
+					State string `json:"state"`
+				}
+				err = json.Unmarshal(status, &job)
+				if err != nil {
+					return
+				}
+				if job.State == "failed" {
+					err = fmt.Errorf("job state is %s", job.State)
+				}
+				done = job.State == "done"
+				return
+			},
+			Interval: time.Millisecond,
+		}
+	})
+
+	JustBeforeEach(func() {
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+		}
+
+		err = gnt.SendAsync(ctx, "POST", "/jobs/", foo{Data: "stuff"}, rcvObj, poll)
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	When("job is accepted and completes", func() {
+		BeforeEach(func() {
+			poll.Result = func(status []byte) (path string, err error) {
+				var job struct {
+					Result string `json:"result"`
+				}
+				err = json.Unmarshal(status, &job)
+				path = job.Result
+				return
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", "/jobs/1")
+					writer.WriteHeader(http.StatusAccepted)
+				case "/jobs/1":
+					polls++
+					if polls < 3 {
+						writer.Header().Set("Retry-After", "0")
+						fmt.Fprint(writer, `{"state": "running"}`)
+						return
+					}
+					fmt.Fprint(writer, `{"state": "done", "result": "/jobs/1/result"}`)
+				case "/jobs/1/result":
+					fmt.Fprint(writer, `{"data": "thing2"}`)
+				}
+			}))
+		})
+
+		It("polls until done and fetches the result", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(polls).To(Equal(3))
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+	})
+
+	When("poll has no done func", func() {
+		BeforeEach(func() {
+			poll.Done = nil
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				polls++
+			}))
+		})
+
+		It("returns an error without submitting", func() {
+			Expect(err).To(MatchError("poll for /jobs/ has no done func"))
+			Expect(polls).To(BeZero())
+		})
+	})
+
+	When("retry after is sooner than the interval", func() {
+		BeforeEach(func() {
+			poll.Interval = time.Hour
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, 5*time.Second)
+			DeferCleanup(cancel)
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				writer.Header().Set("Retry-After", "0")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", "/jobs/1")
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					polls++
+					if polls < 2 {
+						fmt.Fprint(writer, `{"state": "running"}`)
+						return
+					}
+					fmt.Fprint(writer, `{"state": "done", "data": "thing2"}`)
+				}
+			}))
+		})
+
+		It("honors it", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(polls).To(Equal(2))
+		})
+	})
+
+	When("status path is in the submit body", func() {
+		BeforeEach(func() {
+			poll.StatusPath = func(submit []byte) (path string, err error) {
+				var job struct {
+					Status string `json:"status"`
+				}
+				err = json.Unmarshal(submit, &job)
+				path = job.Status
+				return
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.WriteHeader(http.StatusAccepted)
+					fmt.Fprint(writer, `{"status": "/jobs/2/status"}`)
+				case "/jobs/2/status":
+					polls++
+					fmt.Fprint(writer, `{"state": "done", "data": "thing4"}`)
+				}
+			}))
+		})
+
+		It("polls it and decodes the final status", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(polls).To(Equal(1))
+			Expect(rcvObj).To(Equal(&foo{Data: "thing4"}))
+		})
+	})
+
+	When("job completes straight away", func() {
+		BeforeEach(func() {
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				writer.WriteHeader(http.StatusCreated)
+				fmt.Fprint(writer, `{"data": "thing3"}`)
+			}))
+		})
+
+		It("decodes the submit response", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvObj).To(Equal(&foo{Data: "thing3"}))
+		})
+	})
+
+	When("job fails", func() {
+		BeforeEach(func() {
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", fmt.Sprintf("http://%s/jobs/1", request.Host))
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					fmt.Fprint(writer, `{"state": "failed"}`)
+				}
+			}))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("job state is failed")))
+		})
+	})
+
+	When("location is on a lookalike port", func() {
+		BeforeEach(func() {
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", fmt.Sprintf("http://%s0/steal", request.Host))
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					polls++
+				}
+			}))
+		})
+
+		It("refuses to poll it", func() {
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+			Expect(polls).To(BeZero())
+		})
+	})
+
+	When("result is on a lookalike port", func() {
+		BeforeEach(func() {
+			poll.Result = func(status []byte) (path string, err error) {
+				path = fmt.Sprintf("%s0/steal", srv.URL)
+				return
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", "/jobs/1")
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					polls++
+					fmt.Fprint(writer, `{"state": "done"}`)
+				}
+			}))
+		})
+
+		It("refuses to fetch it", func() {
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+			Expect(polls).To(Equal(1))
+		})
+	})
+
+	When("status path from the body is on a lookalike port", func() {
+		BeforeEach(func() {
+			poll.StatusPath = func(submit []byte) (path string, err error) {
+				path = fmt.Sprintf("%s0/steal", srv.URL)
+				return
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					polls++
+				}
+			}))
+		})
+
+		It("refuses to poll it", func() {
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+			Expect(polls).To(BeZero())
+		})
+	})
+
+	When("job never completes", func() {
+		BeforeEach(func() {
+			poll.MaxPolls = 2
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				switch request.URL.Path {
+				case "/jobs/":
+					writer.Header().Set("Location", "/jobs/1")
+					writer.WriteHeader(http.StatusAccepted)
+				default:
+					polls++
+					fmt.Fprint(writer, `{"state": "running"}`)
+				}
+			}))
+		})
+
+		It("gives up after max polls", func() {
+			Expect(err).To(MatchError(ContainSubstring("not done after 2 polls")))
+			Expect(polls).To(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("Retry-After", func() {
+
+	var (
+		header http.Header
+	)
+
+	BeforeEach(func() {
+		header = http.Header{}
+	})
+
+	When("not sent", func() {
+		It("waits the interval", func() {
+			Expect(retryAfter(header, time.Millisecond)).To(Equal(time.Millisecond))
+		})
+	})
+
+	When("sent in seconds", func() {
+		BeforeEach(func() {
+			header.Set("Retry-After", "120")
+		})
+
+		It("waits as much", func() {
+			Expect(retryAfter(header, time.Millisecond)).To(Equal(2 * time.Minute))
+		})
+	})
+
+	When("sent as a date", func() {
+		BeforeEach(func() {
+			header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		})
+
+		It("waits until then", func() {
+			Expect(retryAfter(header, time.Millisecond)).To(BeNumerically("~", time.Hour, 2*time.Second))
+		})
+	})
+
+	When("sent as a date in the past", func() {
+		BeforeEach(func() {
+			header.Set("Retry-After", "Wed, 21 Oct 2015 07:28:00 GMT")
+		})
+
+		It("does not wait", func() {
+			Expect(retryAfter(header, time.Millisecond)).To(BeZero())
+		})
+	})
+
+	When("malformed", func() {
+		BeforeEach(func() {
+			header.Set("Retry-After", "soon")
+		})
+
+		It("waits the interval", func() {
+			Expect(retryAfter(header, time.Millisecond)).To(Equal(time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,401 @@
+package giant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// CborCodec implements Codec with CBOR (RFC 8949), honoring json tags and marshalers.
+// Byte slices are sent as base64 strings, as json would have it, and tags are ignored when decoding.
+type CborCodec struct{}
+
+// ContentType returns application/cbor.
+func (codec CborCodec) ContentType() string {
+	return "application/cbor"
+}
+
+// Encode encodes CBOR.
+func (codec CborCodec) Encode(obj any) (data []byte, err error) {
+
+	tree, err := toTree(obj)
+	if err != nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = writeCbor(buf, tree)
+	data = buf.Bytes()
+	return
+}
+
+// Decode decodes CBOR.
+func (codec CborCodec) Decode(data []byte, obj any) (err error) {
+
+	rdr := &binReader{data: data}
+
+	tree, err := readCbor(rdr)
+	if err != nil {
+		err = errors.Wrap(err, "failed to read cbor")
+		return
+	}
+	if rdr.pos != len(data) {
+		err = errors.Errorf("unexpected %d bytes after cbor value", len(data)-rdr.pos)
+		return
+	}
+
+	err = fromTree(tree, obj)
+	return
+}
+
+// unexported
+
+const (
+	cbUint   byte = 0
+	cbNegInt byte = 1
+	cbBytes  byte = 2
+	cbText   byte = 3
+	cbArray  byte = 4
+	cbMap    byte = 5
+	cbTag    byte = 6
+	cbSimple byte = 7
+
+	cbFalse   byte = 20
+	cbTrue    byte = 21
+	cbNull    byte = 22
+	cbUndef   byte = 23
+	cbFloat16 byte = 25
+	cbFloat32 byte = 26
+	cbFloat64 byte = 27
+	cbIndef   byte = 31
+
+	cbBreak byte = 0xff
+)
+
+func writeCbor(buf *bytes.Buffer, tree any) (err error) {
+
+	switch val := tree.(type) {
+	case nil:
+		buf.WriteByte(cbSimple<<5 | cbNull)
+	case bool:
+		if val {
+			buf.WriteByte(cbSimple<<5 | cbTrue)
+		} else {
+			buf.WriteByte(cbSimple<<5 | cbFalse)
+		}
+	case int64:
+		if val >= 0 {
+			writeCbHead(buf, cbUint, uint64(val))
+		} else {
+			writeCbHead(buf, cbNegInt, uint64(-1-val))
+		}
+	case uint64:
+		writeCbHead(buf, cbUint, val)
+	case float64:
+		if float64(float32(val)) == val {
+			buf.WriteByte(cbSimple<<5 | cbFloat32)
+			buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(val))))
+		} else {
+			buf.WriteByte(cbSimple<<5 | cbFloat64)
+			buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(val)))
+		}
+	case string:
+		writeCbHead(buf, cbText, uint64(len(val)))
+		buf.WriteString(val)
+	case []byte:
+		writeCbHead(buf, cbBytes, uint64(len(val)))
+		buf.Write(val)
+	case []any:
+		writeCbHead(buf, cbArray, uint64(len(val)))
+		for _, item := range val {
+			err = writeCbor(buf, item)
+			if err != nil {
+				return
+			}
+		}
+	case members:
+		writeCbHead(buf, cbMap, uint64(len(val)))
+		for _, mbr := range val {
+			writeCbHead(buf, cbText, uint64(len(mbr.key)))
+			buf.WriteString(mbr.key)
+			err = writeCbor(buf, mbr.val)
+			if err != nil {
+				return
+			}
+		}
+	default:
+		err = errors.Errorf("unsupported cbor value: %T", tree)
+	}
+
+	return
+}
+
+// writeCbHead writes major type and argument in the fewest bytes.
+
+func writeCbHead(buf *bytes.Buffer, major byte, arg uint64) {
+
+	major <<= 5
+
+	switch {
+	case arg < 24:
+		buf.WriteByte(major | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(arg))
+	case arg <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
+
+func readCbor(rdr *binReader) (tree any, err error) {
+
+	ascend, err := rdr.descend()
+	if err != nil {
+		return
+	}
+	defer ascend()
+
+	initial, err := rdr.byte()
+	if err != nil {
+		return
+	}
+
+	major, info := initial>>5, initial&0x1f
+
+	if major == cbSimple {
+		return readCbSimple(rdr, info)
+	}
+
+	if info == cbIndef {
+		return readCbIndef(rdr, major)
+	}
+
+	arg, err := readCbArg(rdr, info)
+	if err != nil {
+		return
+	}
+
+	switch major {
+	case cbUint:
+		tree = arg
+		if arg <= math.MaxInt64 {
+			tree = int64(arg)
+		}
+	case cbNegInt:
+		if arg > math.MaxInt64 {
+			err = errors.Errorf("cbor negative integer out of range: -1-%d", arg)
+			return
+		}
+		tree = -1 - int64(arg)
+	case cbBytes:
+		tree, err = rdr.bytes(arg)
+	case cbText:
+		var data []byte
+		data, err = rdr.bytes(arg)
+		tree = string(data)
+	case cbArray:
+		tree, err = readCbArray(rdr, arg)
+	case cbMap:
+		tree, err = readCbMap(rdr, arg)
+	case cbTag:
+		// ignore tag and take its content, ex: tag 0 date strings
+		tree, err = readCbor(rdr)
+	}
+
+	return
+}
+
+func readCbArg(rdr *binReader, info byte) (arg uint64, err error) {
+
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		arg, err = rdr.uint(1 << (info - 24))
+	default:
+		err = errors.Errorf("unsupported cbor additional info: %d", info)
+	}
+
+	return
+}
+
+func readCbSimple(rdr *binReader, info byte) (val any, err error) {
+
+	var bits uint64
+
+	switch info {
+	case cbFalse:
+		val = false
+	case cbTrue:
+		val = true
+	case cbNull, cbUndef:
+		val = nil
+	case cbFloat16:
+		bits, err = rdr.uint(2)
+		val = float16(uint16(bits))
+	case cbFloat32:
+		bits, err = rdr.uint(4)
+		val = float64(math.Float32frombits(uint32(bits)))
+	case cbFloat64:
+		bits, err = rdr.uint(8)
+		val = math.Float64frombits(bits)
+	default:
+		err = errors.Errorf("unsupported cbor simple value: %d", info)
+	}
+
+	return
+}
+
+func readCbArray(rdr *binReader, length uint64) (array []any, err error) {
+
+	err = rdr.remaining(length)
+	if err != nil {
+		return
+	}
+
+	array = make([]any, 0, length)
+	for range length {
+		var item any
+		item, err = readCbor(rdr)
+		if err != nil {
+			return
+		}
+		array = append(array, item)
+	}
+
+	return
+}
+
+func readCbMap(rdr *binReader, length uint64) (mbrs members, err error) {
+
+	err = rdr.remaining(length)
+	if err != nil {
+		return
+	}
+
+	mbrs = make(members, 0, length)
+	for range length {
+		var mbr member
+		mbr, err = readCbMember(rdr)
+		if err != nil {
+			return
+		}
+		mbrs = append(mbrs, mbr)
+	}
+
+	return
+}
+
+func readCbMember(rdr *binReader) (mbr member, err error) {
+
+	key, err := readCbor(rdr)
+	if err != nil {
+		return
+	}
+
+	mbr.key, err = mapKey(key)
+	if err != nil {
+		return
+	}
+
+	mbr.val, err = readCbor(rdr)
+	return
+}
+
+// readCbIndef reads indefinite length items up to a break.
+
+func readCbIndef(rdr *binReader, major byte) (tree any, err error) {
+
+	switch major {
+	case cbBytes, cbText:
+		chunks := []byte{}
+		for !rdr.atBreak() {
+			var chunk any
+			chunk, err = readCbor(rdr)
+			if err != nil {
+				return
+			}
+			switch val := chunk.(type) {
+			case []byte:
+				chunks = append(chunks, val...)
+			case string:
+				chunks = append(chunks, val...)
+			default:
+				err = errors.Errorf("unexpected cbor chunk: %T", chunk)
+				return
+			}
+		}
+		tree = chunks
+		if major == cbText {
+			tree = string(chunks)
+		}
+	case cbArray:
+		array := []any{}
+		for !rdr.atBreak() {
+			var item any
+			item, err = readCbor(rdr)
+			if err != nil {
+				return
+			}
+			array = append(array, item)
+		}
+		tree = array
+	case cbMap:
+		mbrs := members{}
+		for !rdr.atBreak() {
+			var mbr member
+			mbr, err = readCbMember(rdr)
+			if err != nil {
+				return
+			}
+			mbrs = append(mbrs, mbr)
+		}
+		tree = mbrs
+	default:
+		err = errors.Errorf("unsupported cbor indefinite length for major type: %d", major)
+		return
+	}
+
+	_, err = rdr.byte()
+	return
+}
+
+func (rdr *binReader) atBreak() bool {
+
+	return rdr.pos >= len(rdr.data) || rdr.data[rdr.pos] == cbBreak
+}
+
+// float16 converts half precision bits, per RFC 8949 appendix D.
+
+func float16(half uint16) float64 {
+
+	exp := int(half>>10) & 0x1f
+	mant := float64(half & 0x3ff)
+
+	var val float64
+	switch exp {
+	case 0:
+		val = math.Ldexp(mant, -24)
+	case 31:
+		val = math.Inf(1)
+		if mant != 0 {
+			val = math.NaN()
+		}
+	default:
+		val = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if half&0x8000 != 0 {
+		return -val
+	}
+	return val
+}
@@ -0,0 +1,95 @@
+package giant
+
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CborCodec", func() {
+
+	var (
+		codec CborCodec
+		data  []byte
+		err   error
+	)
+
+	Describe("encoding", func() {
+
+		It("matches rfc 8949 examples", func() {
+			data, err = codec.Encode([]any{0, 23, 24, 1000, -1, -100, true, nil, "a"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hex.EncodeToString(data)).To(Equal("89" + "00" + "17" + "1818" + "1903e8" + "20" + "3863" + "f5" + "f6" + "6161"))
+		})
+
+		It("honors json tags and keeps field order", func() {
+			data, err = codec.Encode(&binObj{Name: "a", Count: 1, Temps: []float64{1.5}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hex.EncodeToString(data)).To(Equal(
+				"a4" + "646e616d65" + "6161" + // "name": "a"
+					"65636f756e74" + "01" + // "count": 1
+					"6574656d7073" + "81" + "fa3fc00000" + // "temps": [1.5]
+					"647768656e" + "f6", // "when": null
+			))
+		})
+	})
+
+	Describe("round trip", func() {
+
+		It("decodes what it encodes, including marshalers", func() {
+			when := civil(time.Date(2023, 6, 14, 11, 0, 0, 0, time.UTC))
+			sent := &binObj{Name: "a", Count: -300, Temps: []float64{26.5, 1e100}, When: &when}
+
+			data, err = codec.Encode(sent)
+			Expect(err).ToNot(HaveOccurred())
+
+			rcvd := &binObj{}
+			err = codec.Decode(data, rcvd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvd).To(Equal(sent))
+		})
+	})
+
+	Describe("decoding", func() {
+
+		It("decodes half floats, tags and indefinite lengths", func() {
+			// {_ "temps": [_ 1.5, -4.0], "name": (_ "a", "b")} with a tagged count
+			data, _ = hex.DecodeString("bf" +
+				"6574656d7073" + "9f" + "f93e00" + "f9c400" + "ff" +
+				"646e616d65" + "7f" + "6161" + "6162" + "ff" +
+				"65636f756e74" + "c1" + "1903e8" +
+				"ff")
+
+			rcvd := &binObj{}
+			err = codec.Decode(data, rcvd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvd).To(Equal(&binObj{Name: "ab", Count: 1000, Temps: []float64{1.5, -4}}))
+		})
+
+		It("errors on truncated data", func() {
+			err = codec.Decode([]byte{0x82, 0x01}, &[]int{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("errors on absurd lengths", func() {
+			err = codec.Decode([]byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, &[]int{})
+			Expect(err).To(MatchError(ContainSubstring("exceeds remaining")))
+		})
+
+		It("errors on deep nesting", func() {
+			var rcvd any
+
+			// a million nested single item arrays, then tags, around a null
+			data = append(bytes.Repeat([]byte{0x81}, 1000000), 0xf6)
+			err = codec.Decode(data, &rcvd)
+			Expect(err).To(MatchError(ContainSubstring("exceeded max depth of 10000")))
+
+			data = append(bytes.Repeat([]byte{0xc0}, 1000000), 0xf6)
+			err = codec.Decode(data, &rcvd)
+			Expect(err).To(MatchError(ContainSubstring("exceeded max depth of 10000")))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+// Package main generates a giant based client from an OpenAPI 3 spec, ex:
+//
+//	//go:generate go run github.com/clarktrimble/giant/cmd/giant-gen -spec petstore.yaml -pkg petstore -out client.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/clarktrimble/giant/gen"
+)
+
+var (
+	version string
+)
+
+func main() {
+
+	specPath := flag.String("spec", "", "path to OpenAPI 3 spec, json or yaml")
+	pkg := flag.String("pkg", "", "package name of generated code")
+	svc := flag.String("svc", "Svc", "name of generated service struct")
+	out := flag.String("out", "", "path to write generated code, stdout when blank")
+	showVersion := flag.Bool("version", false, "show version and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version)
+		return
+	}
+
+	if *specPath == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(*specPath, *out, gen.Options{Package: *pkg, Svc: *svc})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "giant-gen: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, out string, opts gen.Options) (err error) {
+
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return
+	}
+
+	spec, err := gen.Load(data)
+	if err != nil {
+		return
+	}
+
+	code, err := gen.Generate(spec, opts)
+	if err != nil {
+		return
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return
+	}
+
+	err = os.WriteFile(out, code, 0644)
+	return
+}
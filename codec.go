@@ -0,0 +1,261 @@
+package giant
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Codec specifies how objects are encoded and decoded by SendObject and friends.
+type Codec interface {
+	// ContentType is sent as Content-Type and Accept.
+	ContentType() string
+	// Encode encodes an object into data.
+	Encode(obj any) (data []byte, err error)
+	// Decode decodes data into an object.
+	Decode(data []byte, obj any) (err error)
+}
+
+// JsonCodec implements Codec with encoding/json and is the default.
+type JsonCodec struct{}
+
+// ContentType returns application/json.
+func (codec JsonCodec) ContentType() string {
+	return "application/json"
+}
+
+// Encode marshals json.
+func (codec JsonCodec) Encode(obj any) (data []byte, err error) {
+
+	data, err = json.Marshal(obj)
+	return
+}
+
+// Decode unmarshals json.
+func (codec JsonCodec) Decode(data []byte, obj any) (err error) {
+
+	err = json.Unmarshal(data, obj)
+	return
+}
+
+// XmlCodec implements Codec with encoding/xml.
+type XmlCodec struct{}
+
+// ContentType returns application/xml.
+func (codec XmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+// Encode marshals xml.
+func (codec XmlCodec) Encode(obj any) (data []byte, err error) {
+
+	data, err = xml.Marshal(obj)
+	return
+}
+
+// Decode unmarshals xml.
+func (codec XmlCodec) Decode(data []byte, obj any) (err error) {
+
+	err = xml.Unmarshal(data, obj)
+	return
+}
+
+// Strictness specifies checking of response content type before decoding.
+type Strictness int
+
+const (
+	// Lenient decodes when content type is missing, erroring with ContentTypeError
+	// when it is present and does not match, as permissive decoders such as xml take most anything.
+	Lenient Strictness = iota
+	// Checked errors when content type is present and does not match, the same as Lenient.
+	Checked
+	// Strict errors when content type is missing or does not match.
+	Strict
+)
+
+// ContentTypeError represents a response with unexpected content type,
+// as when a proxy returns an html error page with status 200.
+type ContentTypeError struct {
+	// ContentType is from the response, possibly blank.
+	ContentType string
+	// Request is the method and uri of the request.
+	Request string
+	// Snippet is the beginning of the response body.
+	Snippet string
+}
+
+// Error implements the error interface.
+func (err *ContentTypeError) Error() string {
+
+	return fmt.Sprintf("unexpected content type %q in response from %s with body: %s", err.ContentType, err.Request, err.Snippet)
+}
+
+// WithCodec returns a context that overrides the client's codec for requests sent with it.
+func WithCodec(ctx context.Context, codec Codec) context.Context {
+
+	return context.WithValue(ctx, codecKey{}, codec)
+}
+
+// unexported
+
+const (
+	snippetLen int = 200
+)
+
+type codecKey struct{}
+
+// codec returns the codec from context, or the client's, or json as a last resort.
+
+func (giant *Giant) codec(ctx context.Context) Codec {
+
+	codec, ok := ctx.Value(codecKey{}).(Codec)
+	if ok && codec != nil {
+		return codec
+	}
+
+	if giant.Codec != nil {
+		return giant.Codec
+	}
+
+	return JsonCodec{}
+}
+
+// accept lists the codec's content type followed by any additional codecs'.
+
+func (giant *Giant) accept(codec Codec) string {
+
+	types := []string{codec.ContentType()}
+	for _, other := range giant.Codecs {
+		if !slices.Contains(types, other.ContentType()) {
+			types = append(types, other.ContentType())
+		}
+	}
+
+	return strings.Join(types, ", ")
+}
+
+// decodeResponse decodes with the codec matching the response content type,
+// checking content type per client strictness.
+
+func (giant *Giant) decodeResponse(codec Codec, response *http.Response, data []byte, obj any) (err error) {
+
+	if isNil(obj) {
+		return
+	}
+
+	ctype := response.Header.Get("Content-Type")
+	if ctype == "" {
+		if giant.Strictness == Strict {
+			err = newContentTypeError(response, ctype, data)
+			return
+		}
+		err = decode(codec, data, obj)
+		return
+	}
+
+	for _, candidate := range append([]Codec{codec}, giant.Codecs...) {
+		if matchType(candidate.ContentType(), ctype) {
+			err = decode(candidate, data, obj)
+			return
+		}
+	}
+
+	// no match, not decoded in any mode as permissive decoders such as xml take most anything
+
+	err = newContentTypeError(response, ctype, data)
+	return
+}
+
+// matchType matches media types exactly or by subtype, including structured suffix
+// for example: application/xml matches text/xml and application/atom+xml.
+
+func matchType(codecType, responseType string) bool {
+
+	responseType, _, err := mime.ParseMediaType(responseType)
+	if err != nil {
+		return false
+	}
+
+	if responseType == codecType {
+		return true
+	}
+
+	_, codecSub, _ := strings.Cut(codecType, "/")
+	_, responseSub, _ := strings.Cut(responseType, "/")
+	if _, suffix, ok := strings.Cut(responseSub, "+"); ok {
+		responseSub = suffix
+	}
+
+	return codecSub != "" && codecSub == responseSub
+}
+
+func requestLabel(response *http.Response) string {
+
+	if response.Request == nil || response.Request.URL == nil {
+		return "unknown request"
+	}
+
+	return response.Request.Method + " " + response.Request.URL.String()
+}
+
+func newContentTypeError(response *http.Response, ctype string, data []byte) error {
+
+	snippet := data
+	if len(snippet) > snippetLen {
+		snippet = snippet[:snippetLen]
+		for !utf8.Valid(snippet) && len(snippet) > snippetLen-utf8.UTFMax {
+			snippet = snippet[:len(snippet)-1]
+		}
+		snippet = append(slices.Clip(snippet), "..."...)
+	}
+
+	return &ContentTypeError{
+		ContentType: ctype,
+		Request:     requestLabel(response),
+		Snippet:     string(snippet),
+	}
+}
+
+func encode(codec Codec, obj any) (data []byte, err error) {
+
+	data = []byte{}
+	if obj == nil {
+		return
+	}
+
+	data, err = codec.Encode(obj)
+	err = errors.Wrapf(err, "failed to marshal object: %#v", obj)
+	return
+}
+
+// decode skips nil objects, including typed nil pointers.
+
+func decode(codec Codec, data []byte, obj any) (err error) {
+
+	if isNil(obj) {
+		return
+	}
+
+	err = codec.Decode(data, obj)
+	err = errors.Wrapf(err, "failed to decode response into %#v", obj)
+	return
+}
+
+func isNil(obj any) bool {
+
+	if obj == nil {
+		return true
+	}
+
+	val := reflect.ValueOf(obj)
+	return val.Kind() == reflect.Pointer && val.IsNil()
+}
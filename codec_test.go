@@ -0,0 +1,343 @@
+package giant
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Codec", func() {
+
+	var (
+		srv     *httptest.Server
+		gnt     *Giant
+		ctx     context.Context
+		ctype   string
+		accept  string
+		sndBody string
+		rcvObj  *foo
+		err     error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		rcvObj = &foo{}
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctype = request.Header.Get("Content-Type")
+			accept = request.Header.Get("Accept")
+
+			body, err := io.ReadAll(request.Body)
+			Expect(err).ToNot(HaveOccurred())
+			sndBody = string(body)
+
+			if accept == "text/plain" {
+				writer.Write([]byte("data=thing2"))
+				return
+			}
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Write([]byte(`{"data": "thing2"}`))
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	JustBeforeEach(func() {
+		err = gnt.SendObject(ctx, "POST", "/posts/", foo{Data: "stuff"}, rcvObj)
+	})
+
+	When("codec is not set", func() {
+		It("defaults to json", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctype).To(Equal("application/json"))
+			Expect(accept).To(Equal("application/json"))
+			Expect(sndBody).To(Equal(`{"data":"stuff"}`))
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+	})
+
+	When("codec is set on the client", func() {
+		BeforeEach(func() {
+			gnt.Codec = textCodec{}
+		})
+
+		It("encodes, negotiates and decodes with it", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctype).To(Equal("text/plain"))
+			Expect(accept).To(Equal("text/plain"))
+			Expect(sndBody).To(Equal("data=stuff"))
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+	})
+
+	When("codec is set in context", func() {
+		BeforeEach(func() {
+			gnt.Codec = JsonCodec{}
+			ctx = WithCodec(ctx, textCodec{})
+		})
+
+		It("overrides the client's", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctype).To(Equal("text/plain"))
+			Expect(sndBody).To(Equal("data=stuff"))
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+	})
+})
+
+var _ = Describe("Negotiating codecs", func() {
+
+	var (
+		srv    *httptest.Server
+		gnt    *Giant
+		rtype  string
+		rbody  string
+		accept string
+		rcvObj *bar
+		err    error
+	)
+
+	BeforeEach(func() {
+		rcvObj = &bar{}
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			accept = request.Header.Get("Accept")
+			writer.Header().Set("Content-Type", rtype)
+			writer.Write([]byte(rbody))
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+			Codecs:  []Codec{XmlCodec{}},
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	JustBeforeEach(func() {
+		err = gnt.SendObject(context.Background(), "GET", "/reports/", nil, rcvObj)
+	})
+
+	When("response is json", func() {
+		BeforeEach(func() {
+			rtype = "application/json; charset=utf-8"
+			rbody = `{"data": "thing2"}`
+		})
+
+		It("accepts both and decodes json", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accept).To(Equal("application/json, application/xml"))
+			Expect(rcvObj).To(Equal(&bar{Data: "thing2"}))
+		})
+	})
+
+	When("response is xml", func() {
+		BeforeEach(func() {
+			rtype = "text/xml"
+			rbody = `<bar><data>thing3</data></bar>`
+		})
+
+		It("decodes xml", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvObj).To(Equal(&bar{Data: "thing3"}))
+		})
+	})
+
+	When("response is html", func() {
+		BeforeEach(func() {
+			rtype = "text/html"
+			rbody = `<html><body>Oops</body></html>`
+		})
+
+		It("returns a clear error", func() {
+			Expect(err).To(MatchError(ContainSubstring(`unexpected content type "text/html" in response from GET`)))
+		})
+	})
+})
+
+var _ = Describe("Decoding xml", func() {
+
+	var (
+		srv    *httptest.Server
+		gnt    *Giant
+		rcvObj *bar
+		err    error
+	)
+
+	BeforeEach(func() {
+		rcvObj = &bar{}
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "text/html")
+			writer.Write([]byte(`<html><body>proxy error</body></html>`))
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+			Codec:   XmlCodec{},
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	JustBeforeEach(func() {
+		err = gnt.SendObject(context.Background(), "GET", "/reports/", nil, rcvObj)
+	})
+
+	When("html is returned", func() {
+		It("returns a content type error rather than decoding it", func() {
+			var ctErr *ContentTypeError
+			Expect(errors.As(err, &ctErr)).To(BeTrue())
+			Expect(ctErr.ContentType).To(Equal("text/html"))
+			Expect(ctErr.Snippet).To(Equal(`<html><body>proxy error</body></html>`))
+		})
+	})
+})
+
+var _ = Describe("Strictness", func() {
+
+	var (
+		srv    *httptest.Server
+		gnt    *Giant
+		rtype  string
+		rbody  string
+		rcvObj *foo
+		err    error
+	)
+
+	BeforeEach(func() {
+		rcvObj = &foo{}
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			// nil rather than blank to prevent sniffing
+			writer.Header()["Content-Type"] = nil
+			if rtype != "" {
+				writer.Header().Set("Content-Type", rtype)
+			}
+			writer.Write([]byte(rbody))
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	JustBeforeEach(func() {
+		err = gnt.SendObject(context.Background(), "GET", "/posts/", nil, rcvObj)
+	})
+
+	When("html is returned", func() {
+		BeforeEach(func() {
+			rtype = "text/html"
+			rbody = "<html><body>" + strings.Repeat("Bad Gateway ", 30) + "</body></html>"
+		})
+
+		It("returns a content type error with snippet", func() {
+			var ctErr *ContentTypeError
+			Expect(errors.As(err, &ctErr)).To(BeTrue())
+			Expect(ctErr.ContentType).To(Equal("text/html"))
+			Expect(ctErr.Request).To(HavePrefix("GET http://"))
+			Expect(ctErr.Snippet).To(HavePrefix("<html><body>Bad Gateway"))
+			Expect(ctErr.Snippet).To(HaveLen(203))
+		})
+	})
+
+	When("json is returned as text", func() {
+		BeforeEach(func() {
+			rtype = "text/plain"
+			rbody = `{"data": "thing2"}`
+		})
+
+		It("returns a content type error", func() {
+			Expect(err).To(MatchError(ContainSubstring(`unexpected content type "text/plain"`)))
+		})
+
+		When("checked", func() {
+			BeforeEach(func() {
+				gnt.Strictness = Checked
+			})
+
+			It("returns a content type error", func() {
+				Expect(err).To(MatchError(ContainSubstring(`unexpected content type "text/plain"`)))
+			})
+		})
+	})
+
+	When("content type is missing", func() {
+		BeforeEach(func() {
+			rtype = ""
+			rbody = `{"data": "thing2"}`
+		})
+
+		It("decodes anyway", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+
+		When("checked", func() {
+			BeforeEach(func() {
+				gnt.Strictness = Checked
+			})
+
+			It("decodes anyway", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+			})
+		})
+
+		When("strict", func() {
+			BeforeEach(func() {
+				gnt.Strictness = Strict
+			})
+
+			It("returns a content type error", func() {
+				Expect(err).To(MatchError(ContainSubstring(`unexpected content type ""`)))
+			})
+		})
+	})
+})
+
+type bar struct {
+	Data string `json:"data" xml:"data"`
+}
+
+// textCodec is a toy codec for foo's
+
+type textCodec struct{}
+
+func (codec textCodec) ContentType() string {
+	return "text/plain"
+}
+
+func (codec textCodec) Encode(obj any) ([]byte, error) {
+	return []byte("data=" + obj.(foo).Data), nil
+}
+
+func (codec textCodec) Decode(data []byte, obj any) error {
+	obj.(*foo).Data = strings.TrimPrefix(string(data), "data=")
+	return nil
+}
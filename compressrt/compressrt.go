@@ -0,0 +1,256 @@
+// Package compressrt implements the Tripper interface
+// gzipping request bodies and decoding gzip or deflate responses.
+package compressrt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// the stdlib transport decodes gzip transparently only when it sets Accept-Encoding itself
+// here it's set explicitly, so decoding is explicit as well, with a limit
+
+const (
+	defaultThreshold  int   = 1024
+	defaultMaxDecoded int64 = 64 << 20
+	acceptEncoding          = "gzip, deflate"
+)
+
+// ErrTooLarge is returned when reading a response body decompressed beyond MaxDecoded.
+var ErrTooLarge = errors.New("decompressed response body exceeds limit")
+
+// CompressRt implements the Tripper interface.
+type CompressRt struct {
+	// Threshold is the body size in bytes at or above which requests are gzipped.
+	Threshold int
+	// MaxDecoded limits the decompressed size in bytes of response bodies, guarding against zip bombs.
+	MaxDecoded int64
+	next       http.RoundTripper
+}
+
+// New creates a CompressRt, with defaults of 1KiB and 64MiB for zero threshold and max decoded.
+func New(threshold int, maxDecoded int64) *CompressRt {
+
+	if threshold == 0 {
+		threshold = defaultThreshold
+	}
+	if maxDecoded == 0 {
+		maxDecoded = defaultMaxDecoded
+	}
+
+	return &CompressRt{
+		Threshold:  threshold,
+		MaxDecoded: maxDecoded,
+	}
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *CompressRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip compresses the request body when large enough and decodes the response body.
+func (rt *CompressRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	// headers and body are changed, so leave the caller's request be
+
+	request = request.Clone(request.Context())
+
+	err = rt.compress(request)
+	if err != nil {
+		return
+	}
+
+	if request.Header.Get("Accept-Encoding") == "" {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	response, err = rt.next.RoundTrip(request)
+	if err != nil {
+		return
+	}
+
+	err = rt.decode(response)
+	if err != nil {
+		response.Body.Close()
+		response = nil
+	}
+	return
+}
+
+// unexported
+
+func (rt *CompressRt) compress(request *http.Request) (err error) {
+
+	if request.Body == nil || request.Body == http.NoBody || request.Header.Get("Content-Encoding") != "" {
+		return
+	}
+
+	// streamed bodies, described for logging rather than read, are sent as is
+
+	if _, ok := request.Body.(interface{ Describe() any }); ok {
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read request body for compression")
+		return
+	}
+
+	if len(body) < rt.Threshold {
+		setBody(request, body)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	_, err = writer.Write(body)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		err = errors.Wrap(err, "failed to gzip request body")
+		return
+	}
+
+	request.Header.Set("Content-Encoding", "gzip")
+	setBody(request, buf.Bytes())
+	return
+}
+
+func setBody(request *http.Request, body []byte) {
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+func (rt *CompressRt) decode(response *http.Response) (err error) {
+
+	if bodiless(response) {
+		return
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+
+	var reader io.Reader
+	switch encoding {
+	case "", "identity":
+		return
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(response.Body)
+	case "deflate":
+		reader, err = deflateReader(response.Body)
+	default:
+		err = errors.Errorf("unsupported content encoding: %s", encoding)
+		return
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to decode %s response body", encoding)
+		return
+	}
+
+	limit := rt.MaxDecoded
+	if limit == 0 {
+		limit = defaultMaxDecoded
+	}
+
+	response.Body = &limitedBody{
+		reader: reader,
+		closer: response.Body,
+		remain: limit,
+	}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+
+	return
+}
+
+// bodiless is true for responses that carry no body despite a content encoding, ex: HEAD or 304.
+
+func bodiless(response *http.Response) bool {
+
+	if response.Request != nil && response.Request.Method == http.MethodHead {
+		return true
+	}
+
+	switch response.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return true
+	}
+
+	return response.ContentLength == 0
+}
+
+// deflateReader handles zlib wrapped deflate per the rfc and raw deflate as sent by some servers.
+
+func deflateReader(body io.Reader) (reader io.Reader, err error) {
+
+	buffered := bufio.NewReader(body)
+
+	head, err := buffered.Peek(2)
+	if err != nil {
+		return
+	}
+
+	if head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+		reader, err = zlib.NewReader(buffered)
+		return
+	}
+
+	reader = flate.NewReader(buffered)
+	return
+}
+
+// limitedBody errors rather than truncates when reading beyond limit.
+
+type limitedBody struct {
+	reader io.Reader
+	closer io.Closer
+	remain int64
+}
+
+func (body *limitedBody) Read(data []byte) (count int, err error) {
+
+	if body.remain < 0 {
+		return 0, ErrTooLarge
+	}
+
+	// read one beyond remaining to detect excess
+
+	if int64(len(data)) > body.remain+1 {
+		data = data[:body.remain+1]
+	}
+
+	count, err = body.reader.Read(data)
+	body.remain -= int64(count)
+	if body.remain < 0 {
+		count += int(body.remain)
+		err = ErrTooLarge
+	}
+
+	return
+}
+
+func (body *limitedBody) Close() error {
+
+	if closer, ok := body.reader.(io.Closer); ok {
+		closer.Close()
+	}
+
+	return body.closer.Close()
+}
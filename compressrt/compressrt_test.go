@@ -0,0 +1,323 @@
+package compressrt
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCompressRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CompressRt Suite")
+}
+
+var _ = Describe("CompressRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt       *CompressRt
+			trt      *testRt
+			request  *http.Request
+			response *http.Response
+			err      error
+		)
+
+		BeforeEach(func() {
+			trt = &testRt{Status: 200, Body: []byte(`{"ima": "pc"}`)}
+			rt = New(16, 64)
+			rt.Wrap(trt)
+		})
+
+		JustBeforeEach(func() {
+			response, err = rt.RoundTrip(request)
+		})
+
+		Describe("compressing requests", func() {
+
+			When("body is over threshold", func() {
+				BeforeEach(func() {
+					request, err = http.NewRequest("POST", "https://boxworld.org/cardboard", strings.NewReader(strings.Repeat("box", 10)))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("gzips the body", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Header.Get("Content-Encoding")).To(Equal("gzip"))
+					Expect(trt.Header.Get("Accept-Encoding")).To(Equal("gzip, deflate"))
+
+					reader, err := gzip.NewReader(bytes.NewReader(trt.Received))
+					Expect(err).ToNot(HaveOccurred())
+					body, err := io.ReadAll(reader)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(strings.Repeat("box", 10)))
+
+					Expect(request.Header.Get("Content-Encoding")).To(BeEmpty())
+				})
+			})
+
+			When("body is under threshold", func() {
+				BeforeEach(func() {
+					request, err = http.NewRequest("POST", "https://boxworld.org/cardboard", strings.NewReader("box"))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("sends the body as is", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Header.Get("Content-Encoding")).To(BeEmpty())
+					Expect(string(trt.Received)).To(Equal("box"))
+				})
+			})
+
+			When("body is streamed", func() {
+				BeforeEach(func() {
+					body := &streamedBody{ReadCloser: io.NopCloser(strings.NewReader(strings.Repeat("box", 10)))}
+					request, err = http.NewRequest("POST", "https://boxworld.org/cardboard", body)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("sends the body as is", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Header.Get("Content-Encoding")).To(BeEmpty())
+					Expect(string(trt.Received)).To(Equal(strings.Repeat("box", 10)))
+				})
+			})
+		})
+
+		Describe("decoding responses", func() {
+
+			BeforeEach(func() {
+				request, err = http.NewRequest("GET", "https://boxworld.org/cardboard", nil)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			When("response is gzipped", func() {
+				BeforeEach(func() {
+					trt.Encoding = "gzip"
+					trt.Body = compress(trt.Body, gzipWriter)
+				})
+
+				It("decodes the body", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response.Header.Get("Content-Encoding")).To(BeEmpty())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
+				})
+			})
+
+			When("response is deflated with zlib wrapper", func() {
+				BeforeEach(func() {
+					trt.Encoding = "deflate"
+					trt.Body = compress(trt.Body, zlibWriter)
+				})
+
+				It("decodes the body", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
+				})
+			})
+
+			When("response is raw deflated", func() {
+				BeforeEach(func() {
+					trt.Encoding = "deflate"
+					trt.Body = compress(trt.Body, flateWriter)
+				})
+
+				It("decodes the body", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
+				})
+			})
+
+			When("response decompresses beyond limit", func() {
+				BeforeEach(func() {
+					trt.Encoding = "gzip"
+					trt.Body = compress(bytes.Repeat([]byte("0"), 1000), gzipWriter)
+				})
+
+				It("errors on read", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).To(MatchError(ErrTooLarge))
+					Expect(body).To(HaveLen(64))
+				})
+			})
+
+			When("response encoding is in capitals", func() {
+				BeforeEach(func() {
+					trt.Encoding = "GZIP"
+					trt.Body = compress(trt.Body, gzipWriter)
+				})
+
+				It("decodes the body", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
+				})
+			})
+
+			When("response encoding is identity", func() {
+				BeforeEach(func() {
+					trt.Encoding = "identity"
+				})
+
+				It("leaves it alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
+				})
+			})
+
+			When("response is not modified", func() {
+				BeforeEach(func() {
+					trt.Status = 304
+					trt.Encoding = "gzip"
+					trt.Body = nil
+				})
+
+				It("leaves it alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+				})
+			})
+
+			When("response has no content", func() {
+				BeforeEach(func() {
+					trt.Status = 204
+					trt.Encoding = "gzip"
+					trt.Body = nil
+				})
+
+				It("leaves it alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+				})
+			})
+
+			When("response to a head request is gzipped", func() {
+				BeforeEach(func() {
+					request.Method = "HEAD"
+					trt.Encoding = "gzip"
+					trt.Body = []byte("not really")
+				})
+
+				It("leaves it alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+				})
+			})
+
+			When("response is gzipped with zero length", func() {
+				BeforeEach(func() {
+					trt.Encoding = "gzip"
+					trt.Body = nil
+				})
+
+				It("leaves it alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					body, err := io.ReadAll(response.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(body).To(BeEmpty())
+				})
+			})
+
+			When("response encoding is unsupported", func() {
+				BeforeEach(func() {
+					trt.Encoding = "br"
+				})
+
+				It("returns an error", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(response).To(BeNil())
+				})
+			})
+		})
+	})
+})
+
+type streamedBody struct {
+	io.ReadCloser
+}
+
+func (body *streamedBody) Describe() any {
+	return "streamed"
+}
+
+type testRt struct {
+	Status   int
+	Body     []byte
+	Encoding string
+
+	Header   http.Header
+	Received []byte
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	rt.Header = request.Header
+	if request.Body != nil {
+		rt.Received, err = io.ReadAll(request.Body)
+		if err != nil {
+			return
+		}
+	}
+
+	response = &http.Response{
+		StatusCode:    rt.Status,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(rt.Body)),
+		ContentLength: int64(len(rt.Body)),
+		Request:       request,
+	}
+	if rt.Encoding != "" {
+		response.Header.Set("Content-Encoding", rt.Encoding)
+	}
+
+	return
+}
+
+func gzipWriter(writer io.Writer) io.WriteCloser {
+	return gzip.NewWriter(writer)
+}
+
+func zlibWriter(writer io.Writer) io.WriteCloser {
+	return zlib.NewWriter(writer)
+}
+
+func flateWriter(writer io.Writer) io.WriteCloser {
+	fw, _ := flate.NewWriter(writer, flate.DefaultCompression)
+	return fw
+}
+
+func compress(data []byte, newWriter func(io.Writer) io.WriteCloser) []byte {
+
+	buf := &bytes.Buffer{}
+	writer := newWriter(buf)
+	_, err := writer.Write(data)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,234 @@
+package giant
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/statusrt"
+	"github.com/pkg/errors"
+)
+
+// Endpoint declares a request once, to be called with a query Q, decoding the response into R,
+// for example:
+//
+//	var GetForecast = giant.Endpoint[ForecastQuery, Forecast]{Method: "GET", Path: "/v1/forecast"}
+//
+//	forecast, err := GetForecast.Call(ctx, client, query)
+//
+// A struct Q is encoded into path params and query per "path" and "query" tags (see EncodeQuery).
+// For POST, PUT and PATCH, Q is encoded as the body as well, so tag fields that are sent
+// otherwise with `json:"-"` or similar, or have Q implement RequestBodier.
+type Endpoint[Q, R any] struct {
+	// Method is one of the http RFC methods.
+	Method string
+	// Path is appended to BaseUri and may have placeholders, ex: /users/{id}.
+	Path string
+	// Route is the route template, Path (sans query) when blank.
+	Route string
+	// Statuses are those expected, any in the 200's when empty.
+	// When StatusRt is in use, an expected code outside the 200's is recovered from its StatusError
+	// and decoded with the endpoint's codec, as the response's content type is not kept.
+	Statuses []int
+	// Codec overrides the client's codec when set.
+	Codec Codec
+	// Errors map unexpected status codes to errors, wrapped with the StatusError's message.
+	Errors map[int]error
+}
+
+// RequestBodier is implemented by Endpoint queries carrying the request body in a field,
+// nil for none.
+type RequestBodier interface {
+	RequestBody() any
+}
+
+// Call sends a request for the query and decodes the response, skipping decode when the body is empty.
+func (ep Endpoint[Q, R]) Call(ctx context.Context, client *Giant, query Q) (result R, err error) {
+
+	ctx, id := reqid.Ensure(ctx)
+
+	rq, codec, err := ep.request(ctx, client, query)
+	if err != nil {
+		return
+	}
+
+	response, err := client.Send(ctx, rq)
+	if err != nil {
+		result, err = ep.recovered(codec, err)
+		return
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read response from %s %s", ep.Method, ep.Path)
+		return
+	}
+
+	if !ep.expected(response.StatusCode) {
+		err = ep.mapError(&statusrt.StatusError{Code: response.StatusCode, Body: data})
+		err = errors.Wrapf(err, "http %s request to %s %s failed with request_id %s", ep.Method, client.BaseUri, rq.Path, id)
+		return
+	}
+
+	if len(data) > 0 {
+		err = client.decodeResponse(codec, response, data, &result)
+	}
+	return
+}
+
+// Resolve returns the path for the query, with placeholders filled and query appended,
+// for use with Paginate and friends.
+func (ep Endpoint[Q, R]) Resolve(query Q) (path string, err error) {
+
+	path = ep.Path
+	if !isStruct(query) {
+		return
+	}
+
+	params, err := EncodePath(query)
+	if err != nil {
+		return
+	}
+
+	path, _, err = expandPath(ep.Path, params)
+	if err != nil {
+		return
+	}
+
+	values, err := EncodeQuery(query)
+	if err != nil || len(values) == 0 {
+		return
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	path += separator + values.Encode()
+	return
+}
+
+// unexported
+
+func (ep Endpoint[Q, R]) request(ctx context.Context, client *Giant, query Q) (rq Request, codec Codec, err error) {
+
+	codec = ep.Codec
+	if codec == nil {
+		codec = client.codec(ctx)
+	}
+
+	rq = Request{
+		Method: ep.Method,
+		Path:   ep.Path,
+		Route:  ep.Route,
+		Headers: map[string]string{
+			"Accept": client.accept(codec),
+		},
+	}
+
+	if rq.Route == "" {
+		rq.Route, _, _ = strings.Cut(ep.Path, "?")
+	}
+
+	if isStruct(query) {
+		rq.Params, err = EncodePath(query)
+		if err != nil {
+			return
+		}
+		if len(rq.Params) == 0 {
+			rq.Params = nil
+		}
+
+		rq.Query, err = EncodeQuery(query)
+		if err != nil {
+			return
+		}
+	}
+
+	var body any = query
+	if bodier, ok := any(query).(RequestBodier); ok {
+		body = bodier.RequestBody()
+	}
+
+	if hasBody(ep.Method) && body != nil {
+		var data []byte
+		data, err = encode(codec, body)
+		if err != nil {
+			return
+		}
+
+		rq.Body = bytes.NewReader(data)
+		rq.Headers["Content-Type"] = codec.ContentType()
+	}
+
+	return
+}
+
+func (ep Endpoint[Q, R]) expected(code int) bool {
+
+	if len(ep.Statuses) == 0 {
+		return code >= http.StatusOK && code < http.StatusMultipleChoices
+	}
+
+	return slices.Contains(ep.Statuses, code)
+}
+
+// recovered decodes the body of a status error when its code is expected, mapping the error otherwise.
+
+func (ep Endpoint[Q, R]) recovered(codec Codec, sendErr error) (result R, err error) {
+
+	var statusErr *statusrt.StatusError
+	if !errors.As(sendErr, &statusErr) || !ep.expected(statusErr.Code) {
+		err = ep.mapError(sendErr)
+		return
+	}
+
+	if len(statusErr.Body) > 0 {
+		err = decode(codec, statusErr.Body, &result)
+	}
+	return
+}
+
+// mapError maps a status error to the endpoint's error for its code, if any.
+
+func (ep Endpoint[Q, R]) mapError(err error) error {
+
+	var statusErr *statusrt.StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	mapped, ok := ep.Errors[statusErr.Code]
+	if !ok {
+		return err
+	}
+
+	return errors.Wrap(mapped, err.Error())
+}
+
+func hasBody(method string) bool {
+
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+
+	return false
+}
+
+func isStruct(obj any) bool {
+
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Pointer && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	return val.Kind() == reflect.Struct
+}
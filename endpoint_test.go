@@ -0,0 +1,204 @@
+package giant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/clarktrimble/giant/statusrt"
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Endpoint", func() {
+
+	var (
+		srv     *httptest.Server
+		gnt     *Giant
+		status  int
+		uri     string
+		body    string
+		errGone error
+	)
+
+	type userQuery struct {
+		Id   string `path:"id" json:"-"`
+		Full bool   `query:"full,omitempty" json:"-"`
+		Name string `json:"name,omitempty"`
+	}
+
+	BeforeEach(func() {
+		status = 200
+		errGone = errors.New("user is gone")
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+			data, _ := io.ReadAll(request.Body)
+			uri = fmt.Sprintf("%s %s", request.Method, request.RequestURI)
+			body = string(data)
+
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(status)
+			fmt.Fprint(writer, `{"data": "thing2"}`)
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{Transport: http.DefaultTransport},
+			BaseUri: srv.URL,
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	Describe("resolving a path", func() {
+
+		It("fills placeholders and appends query", func() {
+			ep := Endpoint[userQuery, foo]{Method: "GET", Path: "/users/{id}?v=2"}
+
+			path, err := ep.Resolve(userQuery{Id: "a/b", Full: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/users/a%2Fb?v=2&full=true"))
+		})
+	})
+
+	Describe("calling an endpoint", func() {
+		var (
+			ep     Endpoint[userQuery, foo]
+			query  userQuery
+			result foo
+			err    error
+		)
+
+		BeforeEach(func() {
+			ep = Endpoint[userQuery, foo]{
+				Method: "GET",
+				Path:   "/users/{id}",
+				Errors: map[int]error{410: errGone},
+			}
+			query = userQuery{Id: "a b", Full: true, Name: "bob"}
+		})
+
+		JustBeforeEach(func() {
+			result, err = ep.Call(context.Background(), gnt, query)
+		})
+
+		When("method has no body", func() {
+			It("encodes params and query and decodes the result", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(uri).To(Equal("GET /users/a%20b?full=true"))
+				Expect(body).To(BeEmpty())
+				Expect(result).To(Equal(foo{Data: "thing2"}))
+			})
+		})
+
+		When("method has a body", func() {
+			BeforeEach(func() {
+				ep.Method = "PUT"
+			})
+
+			It("encodes the query as body as well", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(uri).To(Equal("PUT /users/a%20b?full=true"))
+				Expect(body).To(Equal(`{"name":"bob"}`))
+			})
+		})
+
+		When("query carries the body", func() {
+			var (
+				bep Endpoint[bodyQuery, foo]
+			)
+
+			BeforeEach(func() {
+				bep = Endpoint[bodyQuery, foo]{Method: "POST", Path: "/users/{id}"}
+			})
+
+			It("encodes only the body", func() {
+				_, err = bep.Call(context.Background(), gnt, bodyQuery{Id: "abc", Body: foo{Data: "thing1"}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(uri).To(Equal("POST /users/abc"))
+				Expect(body).To(Equal(`{"data":"thing1"}`))
+			})
+		})
+
+		When("status is unexpected and mapped", func() {
+			BeforeEach(func() {
+				status = 410
+			})
+
+			It("returns the mapped error", func() {
+				Expect(errors.Is(err, errGone)).To(BeTrue())
+
+				var statusErr *statusrt.StatusError
+				Expect(errors.As(err, &statusErr)).To(BeFalse())
+				Expect(err).To(MatchError(ContainSubstring("unexpected status code 410")))
+			})
+
+			When("StatusRt is in use", func() {
+				BeforeEach(func() {
+					gnt.Use(&statusrt.StatusRt{})
+				})
+
+				It("returns the mapped error", func() {
+					Expect(errors.Is(err, errGone)).To(BeTrue())
+				})
+			})
+		})
+
+		When("status is unexpected and not mapped", func() {
+			BeforeEach(func() {
+				status = 404
+			})
+
+			It("returns a status error", func() {
+				var statusErr *statusrt.StatusError
+				Expect(errors.As(err, &statusErr)).To(BeTrue())
+				Expect(statusErr.Code).To(Equal(404))
+			})
+		})
+
+		When("status is listed as expected", func() {
+			BeforeEach(func() {
+				status = 404
+				ep.Statuses = []int{200, 404}
+			})
+
+			It("decodes the result", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(foo{Data: "thing2"}))
+			})
+
+			When("client has trippers", func() {
+				BeforeEach(func() {
+					cfg := &Config{BaseUri: srv.URL}
+					gnt = cfg.NewWithTrippers(&LoggerMock{
+						InfoFunc:       func(ctx context.Context, msg string, kv ...any) {},
+						DebugFunc:      func(ctx context.Context, msg string, kv ...any) {},
+						TraceFunc:      func(ctx context.Context, msg string, kv ...any) {},
+						ErrorFunc:      func(ctx context.Context, msg string, err error, kv ...any) {},
+						WithFieldsFunc: func(ctx context.Context, kv ...any) context.Context { return ctx },
+					})
+				})
+
+				It("recovers the result from the status error", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result).To(Equal(foo{Data: "thing2"}))
+				})
+			})
+		})
+	})
+})
+
+type bodyQuery struct {
+	Id   string `path:"id"`
+	Body foo
+}
+
+func (query bodyQuery) RequestBody() any {
+	return query.Body
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 
 	"github.com/clarktrimble/giant"
@@ -23,9 +24,13 @@ type Config struct {
 
 func main() {
 
+	fixtures := flag.String("fixtures", "", "serve from fixtures dir rather than the api, ex: examples/weather/fixtures")
+	flag.Parse()
+
 	cfg := &Config{
 		Client: &giant.Config{
-			BaseUri: baseUri,
+			BaseUri:  baseUri,
+			Fixtures: *fixtures,
 		},
 	}
 
@@ -33,13 +38,12 @@ func main() {
 	client := cfg.Client.NewWithTrippers(lgr)
 
 	ctx := context.Background()
-	weatherSvc := &svc.Svc{Client: client}
 
-	hourly, err := weatherSvc.GetHourly(ctx, lat, lon)
+	forecast, err := svc.GetForecast.Call(ctx, client, svc.HourlyQuery(lat, lon))
 	if err != nil {
 		lgr.Error(ctx, "failed to get forcast data", err)
 		os.Exit(1)
 	}
 
-	hourly.Print()
+	forecast.Hourly.Print()
 }
@@ -2,13 +2,23 @@
 package svc
 
 import (
-	"context"
 	"fmt"
-	"strings"
+
+	"github.com/clarktrimble/giant"
 )
 
-type Client interface {
-	SendObject(ctx context.Context, method, path string, snd, rcv any) (err error)
+// GetForecast gets a forecast from open-meteo.
+var GetForecast = giant.Endpoint[ForecastQuery, Forecast]{Method: "GET", Path: "/v1/forecast"}
+
+type ForecastQuery struct {
+	Latitude  float64  `query:"latitude"`
+	Longitude float64  `query:"longitude"`
+	Hourly    []string `query:"hourly,comma"`
+	Days      int      `query:"forecast_days,omitempty"`
+}
+
+type Forecast struct {
+	Hourly Hourly `json:"hourly"`
 }
 
 type Hourly struct {
@@ -18,20 +28,15 @@ type Hourly struct {
 	Wind     []float64   `json:"windspeed_10m"`
 }
 
-type Svc struct {
-	Client Client
-}
-
-func (svc *Svc) GetHourly(ctx context.Context, lat, lon float64) (hourly Hourly, err error) {
+// HourlyQuery returns a query for hourly temperature, humidity and wind today.
+func HourlyQuery(lat, lon float64) ForecastQuery {
 
-	var fc forecast
-	err = svc.Client.SendObject(ctx, "GET", path(lat, lon), nil, &fc)
-	if err != nil {
-		return
+	return ForecastQuery{
+		Latitude:  lat,
+		Longitude: lon,
+		Hourly:    hourlyVars,
+		Days:      days,
 	}
-
-	hourly = fc.Hourly
-	return
 }
 
 func (hourly *Hourly) Print() {
@@ -44,7 +49,6 @@ func (hourly *Hourly) Print() {
 // unexported
 
 var (
-	pathSpec   = "/v1/forecast?latitude=%.2f&longitude=%.2f&hourly=%s&forecast_days=%d"
 	hourlyVars = []string{
 		"temperature_2m",
 		"relativehumidity_2m",
@@ -52,12 +56,3 @@ var (
 	}
 	days = 1
 )
-
-type forecast struct {
-	Hourly Hourly `json:"hourly"`
-}
-
-func path(lat, lon float64) string {
-	vars := strings.Join(hourlyVars, ",")
-	return fmt.Sprintf(pathSpec, lat, lon, vars, days)
-}
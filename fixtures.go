@@ -0,0 +1,229 @@
+package giant
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FixtureHandler is an http.Handler serving responses from files in a directory,
+// for running examples, demos and offline development without a live host.
+//
+// A request for GET /v1/forecast?days=1 is served from the first file found named:
+//
+//	GET/v1/forecast@days=1.<ext>
+//	GET/v1/forecast.<ext>
+//
+// with the query as encoded by url.Values, sorted by key, and Content-Type taken from the extension.
+// The query follows "@" rather than "?", which is not allowed in module zips nor on windows.
+// Optional sidecars alongside set the status, ex: forecast.status holding "404",
+// and headers, ex: forecast.headers holding "Name: value" lines.
+// Requests with no fixture get a 404 naming the files looked for.
+type FixtureHandler struct {
+	Dir string
+}
+
+// NewFixtureHandler creates a FixtureHandler.
+func NewFixtureHandler(dir string) *FixtureHandler {
+
+	return &FixtureHandler{Dir: dir}
+}
+
+// ServeHTTP serves the fixture matching the request's method, path and query.
+func (fh *FixtureHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+
+	if !plainMethod(request.Method) {
+		http.Error(writer, fmt.Sprintf("no fixture for method %q", request.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys := fixtureKeys(request)
+
+	for _, key := range keys {
+		found, err := fh.serve(writer, key)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if found {
+			return
+		}
+	}
+
+	http.Error(writer, fmt.Sprintf("no fixture in %s for %s", fh.Dir, strings.Join(keys, " or ")), http.StatusNotFound)
+}
+
+// unexported
+
+const (
+	statusExt  string = ".status"
+	headersExt string = ".headers"
+)
+
+// fixtureKeys returns fixture names to look for, most specific first.
+
+func fixtureKeys(request *http.Request) (keys []string) {
+
+	name := strings.TrimPrefix(path.Clean("/"+request.URL.Path), "/")
+	if name == "" {
+		name = "index"
+	}
+	key := request.Method + "/" + name
+
+	query := request.URL.Query()
+	if len(query) > 0 {
+		keys = append(keys, key+"@"+query.Encode())
+	}
+	keys = append(keys, key)
+
+	return
+}
+
+// plainMethod is true for methods of only uppercase letters, ex: not "..", which would escape Dir.
+
+func plainMethod(method string) bool {
+
+	if method == "" {
+		return false
+	}
+
+	for _, char := range method {
+		if char < 'A' || char > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// serve writes the fixture for key if found, along with its sidecars.
+
+func (fh *FixtureHandler) serve(writer http.ResponseWriter, key string) (found bool, err error) {
+
+	base := filepath.Join(fh.Dir, filepath.FromSlash(key))
+
+	bodyPath, err := findBody(base)
+	if err != nil || bodyPath == "" {
+		return
+	}
+	found = true
+
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read fixture")
+		return
+	}
+
+	status, err := readStatus(base + statusExt)
+	if err != nil {
+		return
+	}
+
+	header, err := readHeaders(base + headersExt)
+	if err != nil {
+		return
+	}
+
+	if contentType := mime.TypeByExtension(filepath.Ext(bodyPath)); contentType != "" {
+		writer.Header().Set("Content-Type", contentType)
+	}
+	for key, values := range header {
+		writer.Header()[key] = values
+	}
+
+	writer.WriteHeader(status)
+	_, _ = writer.Write(body)
+	return
+}
+
+// findBody returns the path of the first file named base with an extension, other than a sidecar.
+
+func findBody(base string) (bodyPath string, err error) {
+
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to list fixtures")
+		return
+	}
+
+	for _, entry := range entries {
+
+		// the extension must directly follow, ex: not forecast.old.json for forecast
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || ext == "" || ext == statusExt || ext == headersExt || strings.TrimSuffix(name, ext) != filepath.Base(base) {
+			continue
+		}
+
+		bodyPath = filepath.Join(filepath.Dir(base), name)
+		return
+	}
+
+	return
+}
+
+func readStatus(statusPath string) (status int, err error) {
+
+	status = http.StatusOK
+
+	data, err := os.ReadFile(statusPath)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read fixture status")
+		return
+	}
+
+	status, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	err = errors.Wrapf(err, "failed to parse fixture status in %s", statusPath)
+	return
+}
+
+func readHeaders(headersPath string) (header http.Header, err error) {
+
+	header = http.Header{}
+
+	data, err := os.ReadFile(headersPath)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read fixture headers")
+		return
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			err = errors.Errorf("malformed fixture header %q in %s", line, headersPath)
+			return
+		}
+		header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	return
+}
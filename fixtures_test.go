@@ -0,0 +1,113 @@
+package giant
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/clarktrimble/giant/statusrt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FixtureHandler", func() {
+
+	var (
+		cfg *Config
+		gnt *Giant
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		cfg = &Config{
+			BaseUri:  "http://boxworld.internal",
+			Fixtures: "testdata/fixtures",
+		}
+
+		gnt = cfg.New()
+		gnt.Use(&statusrt.StatusRt{})
+		ctx = context.Background()
+	})
+
+	// get skips StatusRt, for a look at error responses
+
+	get := func(path string) (response *http.Response, body string) {
+
+		raw := cfg.New()
+		response, err := raw.Client.Get(raw.BaseUri + path)
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+
+		data, err := io.ReadAll(response.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		body = string(data)
+		return
+	}
+
+	Describe("serving fixtures", func() {
+
+		It("serves by method and path", func() {
+			var rcv []foo
+			err := gnt.SendObject(ctx, "GET", "/boxes", nil, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal([]foo{{Data: "thing1"}}))
+		})
+
+		It("prefers a fixture for the query, falling back to the path", func() {
+			data, err := gnt.SendJson(ctx, "GET", "/boxes?page=2", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("[]\n"))
+
+			data, err = gnt.SendJson(ctx, "GET", "/boxes?page=3", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("[{\"data\": \"thing1\"}]\n"))
+		})
+
+		It("sets content type from the extension", func() {
+			request, err := http.NewRequest("POST", gnt.BaseUri+"/boxes", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			response, err := gnt.Client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+
+			Expect(response.Header.Get("Content-Type")).To(Equal("text/xml; charset=utf-8"))
+		})
+
+		It("sets status and headers from sidecars", func() {
+			response, body := get("/boxes/gone")
+
+			Expect(response.StatusCode).To(Equal(410))
+			Expect(response.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(response.Header.Get("X-Reason")).To(Equal("shredded"))
+			Expect(response.Header.Get("Cache-Control")).To(Equal("no-store"))
+			Expect(body).To(Equal("{\"error\": \"gone\"}\n"))
+		})
+
+		It("responds not found when missing", func() {
+			response, body := get("/crates?size=3")
+
+			Expect(response.StatusCode).To(Equal(404))
+			Expect(body).To(Equal("no fixture in testdata/fixtures for GET/crates@size=3 or GET/crates\n"))
+		})
+
+		It("stays within the directory", func() {
+			response, _ := get("/../../giant.go")
+			Expect(response.StatusCode).To(Equal(404))
+		})
+
+		It("refuses methods that are not plain", func() {
+			for _, method := range []string{"..", "GET.", "get"} {
+				request, err := http.NewRequest(method, "http://boxworld.internal/fixtures/GET/boxes", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				recorder := httptest.NewRecorder()
+				NewFixtureHandler("testdata/fixtures").ServeHTTP(recorder, request)
+				Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+			}
+		})
+	})
+})
@@ -0,0 +1,234 @@
+package giant
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SendForm sends form values url-encoded and unmarshalls the response body into rcvObj
+// (skipped when nil) as SendObject does.
+func (giant *Giant) SendForm(ctx context.Context, method, path string, form url.Values, rcvObj any) (err error) {
+
+	codec := giant.codec(ctx)
+	body := strings.NewReader(form.Encode())
+
+	response, rcvData, err := giant.sendData(ctx, method, path, body, "application/x-www-form-urlencoded", giant.accept(codec))
+	if err != nil {
+		return
+	}
+
+	err = giant.decodeResponse(codec, response, rcvData, rcvObj)
+	return
+}
+
+// EncodeForm encodes a struct into form values per "form" field tags,
+// for example:
+//
+//	type Login struct {
+//		User  string   `form:"username"`
+//		Scope []string `form:"scope,omitempty"`
+//	}
+//
+// Slices are encoded as repeated values, or joined with the "comma" option.
+// Times are encoded as RFC 3339, or per "date", "unix" or "unixmilli" options,
+// or per a layout tag such as `layout:"2006-01-02T15:04"`.
+// Untagged fields are skipped.
+func EncodeForm(obj any) (form url.Values, err error) {
+
+	form, err = encodeValues(obj, "form")
+	return
+}
+
+// unexported
+
+const (
+	unixLayout      string = "unix"
+	unixMilliLayout string = "unixmilli"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// encodeValues encodes tagged struct fields into url values.
+// Tags are of the form `tag:"name,opt,opt"` with "omitempty", "comma"
+// and time formats as options.
+
+func encodeValues(obj any, tag string) (values url.Values, err error) {
+
+	values = url.Values{}
+
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		err = errors.Errorf("cannot encode %s values from non-struct: %#v", tag, obj)
+		return
+	}
+
+	for i := range val.NumField() {
+
+		field := val.Type().Field(i)
+		name, opts, ok := tagOpts(field, tag)
+		if !ok {
+			continue
+		}
+
+		fieldVal := val.Field(i)
+		if opts["omitempty"] && fieldVal.IsZero() {
+			continue
+		}
+
+		var strs []string
+		strs, err = encodeField(fieldVal, timeLayout(field, opts))
+		if err != nil {
+			err = errors.Wrapf(err, "failed to encode %s field %s", tag, field.Name)
+			return
+		}
+
+		if opts["comma"] && len(strs) > 0 {
+			strs = []string{strings.Join(strs, ",")}
+		}
+
+		for _, str := range strs {
+			values.Add(name, str)
+		}
+	}
+
+	return
+}
+
+func tagOpts(field reflect.StructField, tag string) (name string, opts map[string]bool, ok bool) {
+
+	if !field.IsExported() {
+		return
+	}
+
+	value, ok := field.Tag.Lookup(tag)
+	if !ok || value == "-" {
+		ok = false
+		return
+	}
+
+	name, rest, _ := strings.Cut(value, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	opts = map[string]bool{}
+	for _, opt := range strings.Split(rest, ",") {
+		if opt != "" {
+			opts[opt] = true
+		}
+	}
+
+	return
+}
+
+// timeLayout returns the layout tag, or a layout per option, blank for the default.
+
+func timeLayout(field reflect.StructField, opts map[string]bool) string {
+
+	if layout := field.Tag.Get("layout"); layout != "" {
+		return layout
+	}
+
+	for _, opt := range []string{unixLayout, unixMilliLayout} {
+		if opts[opt] {
+			return opt
+		}
+	}
+
+	if opts["date"] {
+		return time.DateOnly
+	}
+
+	return ""
+}
+
+// encodeField returns a string per value, more than one for slices.
+
+func encodeField(val reflect.Value, layout string) (strs []string, err error) {
+
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	if (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && !val.Type().Implements(textMarshalerType) {
+		for i := range val.Len() {
+			var str string
+			str, err = encodeScalar(val.Index(i), layout)
+			if err != nil {
+				return
+			}
+			strs = append(strs, str)
+		}
+		return
+	}
+
+	str, err := encodeScalar(val, layout)
+	if err != nil {
+		return
+	}
+
+	strs = []string{str}
+	return
+}
+
+func encodeScalar(val reflect.Value, layout string) (str string, err error) {
+
+	if val.Type() == timeType {
+		str = encodeTime(val.Interface().(time.Time), layout)
+		return
+	}
+
+	if val.Type().Implements(textMarshalerType) {
+		var data []byte
+		data, err = val.Interface().(encoding.TextMarshaler).MarshalText()
+		str = string(data)
+		return
+	}
+
+	switch val.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		str = fmt.Sprint(val.Interface())
+	default:
+		err = errors.Errorf("unsupported kind: %s", val.Kind())
+	}
+
+	return
+}
+
+func encodeTime(tm time.Time, layout string) string {
+
+	switch layout {
+	case "":
+		return tm.Format(time.RFC3339)
+	case unixLayout:
+		return strconv.FormatInt(tm.Unix(), 10)
+	case unixMilliLayout:
+		return strconv.FormatInt(tm.UnixMilli(), 10)
+	}
+
+	return tm.Format(layout)
+}
@@ -0,0 +1,106 @@
+package giant
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Form", func() {
+
+	Describe("sending a form", func() {
+		var (
+			ts     *testServer
+			gnt    *Giant
+			rcvObj *foo
+			err    error
+		)
+
+		BeforeEach(func() {
+			ts = newTestServer(`{"data": "thing2"}`)
+			gnt = &Giant{
+				Client:  http.Client{},
+				BaseUri: ts.Server.URL,
+			}
+			rcvObj = &foo{}
+		})
+
+		AfterEach(func() {
+			ts.Server.Close()
+		})
+
+		JustBeforeEach(func() {
+			form := url.Values{"name": {"bob smith"}, "tag": {"a", "b"}}
+			err = gnt.SendForm(context.Background(), "POST", "/people/", form, rcvObj)
+		})
+
+		It("url-encodes the body and unmarshalls receive", func() {
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ts.ContentHeader).To(Equal("application/x-www-form-urlencoded"))
+			Expect(ts.Method).To(Equal("POST"))
+			Expect(ts.Path).To(Equal("/people/"))
+			Expect(ts.Body).To(Equal("name=bob+smith&tag=a&tag=b"))
+
+			Expect(rcvObj).To(Equal(&foo{Data: "thing2"}))
+		})
+	})
+
+	Describe("encoding a struct", func() {
+		var (
+			obj  any
+			form url.Values
+			err  error
+		)
+
+		JustBeforeEach(func() {
+			form, err = EncodeForm(obj)
+		})
+
+		When("fields are tagged", func() {
+			BeforeEach(func() {
+				count := 3
+				obj = &struct {
+					User    string    `form:"username"`
+					Scope   []string  `form:"scope"`
+					Count   *int      `form:"count"`
+					Since   time.Time `form:"since"`
+					Missing string    `form:"missing,omitempty"`
+					Skipped string
+					Ignored string `form:"-"`
+				}{
+					User:    "bob",
+					Scope:   []string{"read", "write"},
+					Count:   &count,
+					Since:   time.Date(2023, 6, 14, 11, 0, 0, 0, time.UTC),
+					Skipped: "nope",
+					Ignored: "nope",
+				}
+			})
+
+			It("encodes per tags", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(form).To(Equal(url.Values{
+					"username": {"bob"},
+					"scope":    {"read", "write"},
+					"count":    {"3"},
+					"since":    {"2023-06-14T11:00:00Z"},
+				}))
+			})
+		})
+
+		When("obj is not a struct", func() {
+			BeforeEach(func() {
+				obj = "bargle"
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,947 @@
+// Package gen generates giant based clients from OpenAPI 3 specs.
+//
+// Component schemas become Go types and each operation an Endpoint and a method on a
+// service struct, with path and query params, json request bodies and typed error responses.
+// Operations having the x-pagination extension get a method iterating over all pages as well.
+// Header and cookie params are not generated.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Options are for Generate.
+type Options struct {
+	// Package is the package name of generated code.
+	Package string
+	// Svc is the name of the service struct, "Svc" when blank.
+	Svc string
+}
+
+// Generate generates formatted client code from a spec.
+func Generate(spec *Spec, opts Options) (code []byte, err error) {
+
+	if opts.Svc == "" {
+		opts.Svc = "Svc"
+	}
+
+	gnr := &generator{
+		spec:    spec,
+		opts:    opts,
+		names:   map[string]bool{},
+		structs: map[string]bool{},
+		schemas: map[*Schema]string{},
+		imports: map[string]bool{},
+	}
+
+	err = gnr.generate()
+	if err != nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = fileTemplate.Execute(buf, gnr)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to execute template")
+		return
+	}
+
+	code, err = format.Source(buf.Bytes())
+	err = errors.Wrapf(err, "failed to format generated code")
+	return
+}
+
+// unexported
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+type generator struct {
+	spec    *Spec
+	opts    Options
+	Types   []*typeDecl
+	Ops     []*operation
+	names   map[string]bool
+	structs map[string]bool
+	schemas map[*Schema]string
+	imports map[string]bool
+}
+
+type typeDecl struct {
+	Name       string
+	Doc        string
+	Underlying string
+	Fields     []field
+	Enum       []enumValue
+	// Body is the name of the body field of operation params, if any.
+	Body string
+	// NilBody is set when the body is optional and nil when unset.
+	NilBody  bool
+	IsParams bool
+}
+
+type field struct {
+	Name string
+	Type string
+	Tag  string
+	Doc  string
+}
+
+type enumValue struct {
+	Name  string
+	Value string
+}
+
+type operation struct {
+	Name       string
+	Doc        string
+	Method     string
+	Path       string
+	Params     string
+	Result     string
+	Statuses   []int
+	Errors     []opError
+	Default    string
+	Pagination *pagination
+}
+
+type opError struct {
+	Code int
+	Type string
+}
+
+type pagination struct {
+	Pager    string
+	ItemsKey string
+	Item     string
+}
+
+func (gnr *generator) Package() string {
+	return gnr.opts.Package
+}
+
+func (gnr *generator) Svc() string {
+	return gnr.opts.Svc
+}
+
+func (gnr *generator) Title() string {
+	return gnr.spec.Info.Title
+}
+
+// Imports returns sorted imports, stdlib first.
+func (gnr *generator) Imports() (std, other []string) {
+
+	for imp := range gnr.imports {
+		if strings.Contains(imp, ".") {
+			other = append(other, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+
+	slices.Sort(std)
+	slices.Sort(other)
+	return
+}
+
+func (gnr *generator) StdImports() []string {
+	std, _ := gnr.Imports()
+	return std
+}
+
+func (gnr *generator) OtherImports() []string {
+	_, other := gnr.Imports()
+	return other
+}
+
+func (gnr *generator) NoParams() bool {
+	return slices.ContainsFunc(gnr.Ops, func(op *operation) bool { return op.Params == noParams })
+}
+
+func (gnr *generator) MapsErrors() bool {
+	return slices.ContainsFunc(gnr.Ops, (*operation).MapsErrors)
+}
+
+func (op *operation) MapsErrors() bool {
+	return len(op.Errors) > 0 || op.Default != ""
+}
+
+func (op *operation) Var() string {
+	return lowerFirst(op.Name) + "Endpoint"
+}
+
+func (op *operation) PaginationVar() string {
+	return lowerFirst(op.Name) + "Pagination"
+}
+
+func (op *operation) ResultType() string {
+	if op.Result == "" {
+		return "struct{}"
+	}
+	return op.Result
+}
+
+const (
+	noParams string = "noParams"
+)
+
+func (gnr *generator) generate() (err error) {
+
+	gnr.imports["context"] = true
+	gnr.imports["github.com/clarktrimble/giant"] = true
+
+	// claim component names up front so inline types steer clear
+
+	for _, name := range gnr.spec.Components.Schemas.Keys {
+		gnr.names[goName(name)] = true
+		if isStruct(gnr.spec.Components.Schemas.Values[name]) {
+			gnr.structs[goName(name)] = true
+		}
+	}
+
+	for _, name := range gnr.spec.Components.Schemas.Keys {
+		err = gnr.component(name, gnr.spec.Components.Schemas.Values[name])
+		if err != nil {
+			err = errors.Wrapf(err, "failed on schema %s", name)
+			return
+		}
+	}
+
+	for _, path := range gnr.spec.Paths.Keys {
+
+		item := gnr.spec.Paths.Values[path]
+		for _, method := range methods {
+
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+
+			err = gnr.operation(method, path, item, op)
+			if err != nil {
+				err = errors.Wrapf(err, "failed on %s %s", method, path)
+				return
+			}
+		}
+	}
+
+	if gnr.MapsErrors() {
+		gnr.imports["encoding/json"] = true
+		gnr.imports["errors"] = true
+		gnr.imports["github.com/clarktrimble/giant/statusrt"] = true
+	}
+
+	return
+}
+
+func (item *PathItem) operation(method string) *Operation {
+
+	switch method {
+	case "GET":
+		return item.Get
+	case "POST":
+		return item.Post
+	case "PUT":
+		return item.Put
+	case "PATCH":
+		return item.Patch
+	case "DELETE":
+		return item.Delete
+	}
+
+	return nil
+}
+
+// component declares a type for a component schema.
+
+func (gnr *generator) component(name string, schema *Schema) (err error) {
+
+	name = goName(name)
+	gnr.schemas[schema] = name
+
+	if schema.Ref != "" || isStruct(schema) {
+		_, err = gnr.declare(name, schema)
+		return
+	}
+
+	decl := &typeDecl{Name: name, Doc: schema.Description}
+
+	if schema.Type == "string" && len(schema.Enum) > 0 {
+		decl.Underlying = "string"
+		for _, value := range schema.Enum {
+			str := fmt.Sprint(value)
+			decl.Enum = append(decl.Enum, enumValue{Name: name + goName(str), Value: strconv.Quote(str)})
+		}
+		gnr.Types = append(gnr.Types, decl)
+		return
+	}
+
+	// not yet appended so as to follow any inline types it declares
+
+	decl.Underlying, err = gnr.goType(&Schema{
+		Type:                 schema.Type,
+		Format:               schema.Format,
+		Items:                schema.Items,
+		AdditionalProperties: schema.AdditionalProperties,
+	}, name)
+	if err != nil {
+		return
+	}
+
+	gnr.Types = append(gnr.Types, decl)
+	return
+}
+
+// goType returns the Go type for a schema, declaring named types as needed, hinted at by name.
+
+func (gnr *generator) goType(schema *Schema, hint string) (typ string, err error) {
+
+	if schema == nil {
+		typ = "any"
+		return
+	}
+
+	if name, ok := gnr.schemas[schema]; ok {
+		typ = name
+		return
+	}
+
+	if schema.Ref != "" {
+		_, _, err = gnr.spec.schema(schema)
+		typ = goName(strings.TrimPrefix(schema.Ref, schemaPrefix))
+		return
+	}
+
+	if isStruct(schema) {
+		typ, err = gnr.declare(gnr.unique(hint), schema)
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		var elem string
+		elem, err = gnr.additional(schema, hint)
+		typ = "map[string]" + elem
+	case "array":
+		var elem string
+		elem, err = gnr.goType(schema.Items, hint+"Item")
+		typ = "[]" + elem
+	case "string":
+		typ = stringType(schema.Format)
+		if typ == "time.Time" {
+			gnr.imports["time"] = true
+		}
+	case "integer":
+		typ = "int"
+		if schema.Format == "int32" || schema.Format == "int64" {
+			typ = schema.Format
+		}
+	case "number":
+		typ = "float64"
+		if schema.Format == "float" {
+			typ = "float32"
+		}
+	case "boolean":
+		typ = "bool"
+	default:
+		typ = "any"
+	}
+
+	return
+}
+
+func stringType(format string) string {
+
+	switch format {
+	case "date-time":
+		return "time.Time"
+	case "byte":
+		return "[]byte"
+	}
+
+	return "string"
+}
+
+// additional returns the map value type for additionalProperties.
+
+func (gnr *generator) additional(schema *Schema, hint string) (typ string, err error) {
+
+	props, ok := schema.AdditionalProperties.(map[string]any)
+	if !ok || len(props) == 0 {
+		typ = "any"
+		return
+	}
+
+	value := &Schema{}
+	if ref, ok := props["$ref"].(string); ok {
+		value.Ref = ref
+	}
+	if kind, ok := props["type"].(string); ok {
+		value.Type = Type(kind)
+	}
+	if format, ok := props["format"].(string); ok {
+		value.Format = format
+	}
+
+	typ, err = gnr.goType(value, hint+"Value")
+	return
+}
+
+// declare declares a struct for an object schema, embedding referenced allOf parts.
+
+func (gnr *generator) declare(name string, schema *Schema) (typ string, err error) {
+
+	typ = name
+	gnr.schemas[schema] = name
+	gnr.structs[name] = true
+
+	decl := &typeDecl{Name: name, Doc: schema.Description}
+	if schema.Ref != "" {
+		decl.Fields = []field{{Type: goName(strings.TrimPrefix(schema.Ref, schemaPrefix))}}
+	}
+
+	err = gnr.fields(decl, schema, schema.Required)
+	if err != nil {
+		return
+	}
+
+	gnr.Types = append(gnr.Types, decl)
+	return
+}
+
+func (gnr *generator) fields(decl *typeDecl, schema *Schema, required []string) (err error) {
+
+	for _, part := range schema.AllOf {
+
+		if part.Ref != "" {
+			var typ string
+			typ, err = gnr.goType(part, "")
+			if err != nil {
+				return
+			}
+			decl.Fields = append(decl.Fields, field{Type: typ})
+			continue
+		}
+
+		err = gnr.fields(decl, part, append(slices.Clone(required), part.Required...))
+		if err != nil {
+			return
+		}
+	}
+
+	for _, prop := range schema.Properties.Keys {
+
+		propName := goName(prop)
+		isRequired := slices.Contains(required, prop)
+
+		var typ string
+		typ, err = gnr.goType(schema.Properties.Values[prop], decl.Name+propName)
+		if err != nil {
+			return
+		}
+
+		tag := prop
+		if !isRequired {
+			tag += ",omitempty"
+			if gnr.structs[typ] || typ == "time.Time" {
+				typ = "*" + typ
+			}
+		}
+
+		decl.Fields = append(decl.Fields, field{
+			Name: propName,
+			Type: typ,
+			Tag:  fmt.Sprintf("`json:%q`", tag),
+			Doc:  schema.Properties.Values[prop].Description,
+		})
+	}
+
+	return
+}
+
+func (gnr *generator) operation(method, path string, item *PathItem, spec *Operation) (err error) {
+
+	name := spec.OperationId
+	if name == "" {
+		name = strings.ToLower(method) + " " + path
+	}
+
+	op := &operation{
+		Name:   gnr.unique(goName(name)),
+		Doc:    firstLine(spec.Summary, spec.Description),
+		Method: method,
+		Path:   path,
+	}
+
+	op.Params, err = gnr.params(op.Name, item, spec)
+	if err != nil {
+		return
+	}
+
+	err = gnr.responses(op, spec)
+	if err != nil {
+		return
+	}
+
+	if spec.Pagination != nil {
+		op.Pagination, err = gnr.pagination(op, spec)
+		if err != nil {
+			return
+		}
+		gnr.imports["iter"] = true
+	}
+
+	gnr.Ops = append(gnr.Ops, op)
+	return
+}
+
+// params declares a struct for path and query params and request body, if any.
+
+func (gnr *generator) params(opName string, item *PathItem, spec *Operation) (typ string, err error) {
+
+	decl := &typeDecl{Name: gnr.unique(opName + "Params"), IsParams: true}
+
+	// operation params override path item params of the same name and location
+
+	params := []*Parameter{}
+	for _, param := range append(slices.Clone(item.Parameters), spec.Parameters...) {
+
+		var resolved *Parameter
+		resolved, err = gnr.spec.parameter(param)
+		if err != nil {
+			return
+		}
+
+		params = slices.DeleteFunc(params, func(prev *Parameter) bool {
+			return prev.Name == resolved.Name && prev.In == resolved.In
+		})
+		params = append(params, resolved)
+	}
+
+	for _, param := range params {
+
+		if param.In != "path" && param.In != "query" {
+			continue
+		}
+
+		fieldName := goName(param.Name)
+
+		var fieldType string
+		fieldType, err = gnr.goType(param.Schema, opName+fieldName)
+		if err != nil {
+			return
+		}
+
+		tag := param.Name
+		if param.In == "query" && !param.Required {
+			tag += ",omitempty"
+		}
+		if param.In == "query" && param.Explode != nil && !*param.Explode {
+			tag += ",comma"
+		}
+
+		decl.Fields = append(decl.Fields, field{
+			Name: fieldName,
+			Type: fieldType,
+			Tag:  fmt.Sprintf("`%s:%q`", param.In, tag),
+			Doc:  param.Description,
+		})
+	}
+
+	if spec.RequestBody != nil {
+
+		var body *Body
+		body, err = gnr.spec.body(spec.RequestBody)
+		if err != nil {
+			return
+		}
+
+		var bodyType string
+		bodyType, err = gnr.goType(jsonSchema(body.Content), opName+"Body")
+		if err != nil {
+			return
+		}
+
+		// optional bodies are pointers, so as not to send a zero value when unset
+
+		if !body.Required && bodyType != "any" {
+			if !strings.HasPrefix(bodyType, "[]") && !strings.HasPrefix(bodyType, "map[") {
+				bodyType = "*" + bodyType
+			}
+			decl.NilBody = true
+		}
+
+		decl.Body = uniqueField(decl.Fields, "Body")
+		decl.Fields = append(decl.Fields, field{Name: decl.Body, Type: bodyType})
+	}
+
+	if len(decl.Fields) == 0 {
+		delete(gnr.names, decl.Name)
+		typ = noParams
+		return
+	}
+
+	gnr.Types = append(gnr.Types, decl)
+	typ = decl.Name
+	return
+}
+
+// responses finds the result type and expected statuses from 2xx responses,
+// and error types from 4xx and 5xx and default responses.
+
+func (gnr *generator) responses(op *operation, spec *Operation) (err error) {
+
+	numeric := true
+
+	for _, code := range spec.Responses.Keys {
+
+		var response *Response
+		response, err = gnr.spec.response(spec.Responses.Values[code])
+		if err != nil {
+			return
+		}
+
+		schema := jsonSchema(response.Content)
+		status, convErr := strconv.Atoi(code)
+
+		switch {
+		case strings.HasPrefix(code, "2"):
+			if convErr != nil {
+				numeric = false
+			} else {
+				op.Statuses = append(op.Statuses, status)
+			}
+			if schema != nil && op.Result == "" {
+				op.Result, err = gnr.goType(schema, op.Name+"Result")
+			}
+		case schema == nil:
+			continue
+		case code == "default":
+			op.Default, err = gnr.goType(schema, op.Name+"Error")
+		case convErr == nil && status >= 400:
+			var typ string
+			typ, err = gnr.goType(schema, op.Name+code+"Error")
+			op.Errors = append(op.Errors, opError{Code: status, Type: typ})
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if !numeric {
+		op.Statuses = nil
+	}
+	return
+}
+
+func (gnr *generator) pagination(op *operation, spec *Operation) (pgn *pagination, err error) {
+
+	hint := spec.Pagination
+	pgn = &pagination{ItemsKey: hint.ItemsKey}
+
+	switch hint.Style {
+	case "link":
+		pgn.Pager = "giant.LinkPager{}"
+	case "cursor":
+		pgn.Pager = fmt.Sprintf("giant.CursorPager{Field: %q, Param: %q}", hint.Field, hint.Param)
+	case "offset":
+		pgn.Pager = fmt.Sprintf("giant.OffsetPager{Param: %q, LimitParam: %q, Limit: %d}", hint.Param, hint.LimitParam, hint.Limit)
+	case "page":
+		pgn.Pager = fmt.Sprintf("giant.PagePager{Param: %q, SizeParam: %q, Size: %d}", hint.Param, hint.LimitParam, hint.Limit)
+	default:
+		err = errors.Errorf("unknown pagination style: %q", hint.Style)
+		return
+	}
+
+	// items are the result array or an array field of the result
+
+	var schema *Schema
+	for _, code := range spec.Responses.Keys {
+		response, _ := gnr.spec.response(spec.Responses.Values[code])
+		if strings.HasPrefix(code, "2") && response != nil {
+			schema = jsonSchema(response.Content)
+			break
+		}
+	}
+
+	_, schema, err = gnr.spec.schema(schema)
+	if err != nil {
+		return
+	}
+	if schema != nil && hint.ItemsKey != "" {
+		schema = schema.Properties.Values[hint.ItemsKey]
+		_, schema, err = gnr.spec.schema(schema)
+		if err != nil {
+			return
+		}
+	}
+	if schema == nil || schema.Type != "array" {
+		err = errors.Errorf("paginated result has no array of items at %q", hint.ItemsKey)
+		return
+	}
+
+	pgn.Item, err = gnr.goType(schema.Items, op.Name+"Item")
+	return
+}
+
+// unique returns the name, or the name with the lowest numeric suffix not yet in use, and claims it.
+
+func (gnr *generator) unique(name string) string {
+
+	candidate := name
+	for i := 2; gnr.names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+
+	gnr.names[candidate] = true
+	return candidate
+}
+
+func uniqueField(fields []field, name string) string {
+
+	candidate := name
+	for i := 2; slices.ContainsFunc(fields, func(fld field) bool { return fld.Name == candidate }); i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+
+	return candidate
+}
+
+func isStruct(schema *Schema) bool {
+
+	return len(schema.AllOf) > 0 || len(schema.Properties.Keys) > 0
+}
+
+// goName converts a name from a spec into an exported Go identifier, ex: pet_id to PetId.
+
+func goName(name string) string {
+
+	var builder strings.Builder
+	upper := true
+
+	for _, char := range name {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			if builder.Len() == 0 && unicode.IsDigit(char) {
+				builder.WriteRune('X')
+			}
+			if upper {
+				char = unicode.ToUpper(char)
+				upper = false
+			}
+			builder.WriteRune(char)
+		default:
+			upper = true
+		}
+	}
+
+	if builder.Len() == 0 {
+		return "X"
+	}
+
+	return builder.String()
+}
+
+func lowerFirst(name string) string {
+
+	if name == "" {
+		return name
+	}
+
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func firstLine(strs ...string) string {
+
+	for _, str := range strs {
+		if str = strings.TrimSpace(str); str != "" {
+			line, _, _ := strings.Cut(str, "\n")
+			return line
+		}
+	}
+
+	return ""
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"comment": func(doc string) string {
+		doc = firstLine(doc)
+		if doc == "" {
+			return ""
+		}
+		return "// " + doc
+	},
+}).Parse(`// Code generated by giant-gen; DO NOT EDIT.
+
+{{ if .Title }}// Package {{ .Package }} is a client for {{ .Title }}.
+{{ end }}package {{ .Package }}
+
+import (
+{{- range .StdImports }}
+	"{{ . }}"
+{{- end }}
+{{ range .OtherImports }}
+	"{{ . }}"
+{{- end }}
+)
+
+// {{ .Svc }} calls api operations with a giant client.
+type {{ .Svc }} struct {
+	Client *giant.Giant
+}
+{{ range .Types }}
+{{- if .Doc }}
+{{ comment .Doc }}
+{{- end }}
+{{- if .Enum }}
+type {{ .Name }} {{ .Underlying }}
+
+const (
+{{- $type := .Name }}
+{{- range .Enum }}
+	{{ .Name }} {{ $type }} = {{ .Value }}
+{{- end }}
+)
+{{ else if .Underlying }}
+type {{ .Name }} {{ .Underlying }}
+{{ else }}
+type {{ .Name }} struct {
+{{- range .Fields }}
+{{- if .Doc }}
+	{{ comment .Doc }}
+{{- end }}
+	{{ .Name }} {{ .Type }} {{ .Tag }}
+{{- end }}
+}
+{{ if .IsParams }}
+// RequestBody implements giant.RequestBodier.
+func (params {{ .Name }}) RequestBody() any {
+{{- if .Body }}
+{{- if .NilBody }}
+	if params.{{ .Body }} == nil {
+		return nil
+	}
+{{- end }}
+	return params.{{ .Body }}
+{{- else }}
+	return nil
+{{- end }}
+}
+{{ end }}
+{{- end }}
+{{- end }}
+{{- range .Ops }}
+var {{ .Var }} = giant.Endpoint[{{ .Params }}, {{ .ResultType }}]{
+	Method: "{{ .Method }}",
+	Path:   "{{ .Path }}",
+{{- if .Statuses }}
+	Statuses: []int{ {{- range $i, $code := .Statuses }}{{ if $i }}, {{ end }}{{ $code }}{{ end -}} },
+{{- end }}
+}
+
+// {{ .Name }} calls {{ .Method }} {{ .Path }}.
+{{- if .Doc }}
+// {{ .Doc }}
+{{- end }}
+func (svc *{{ $.Svc }}) {{ .Name }}(ctx context.Context{{ if ne .Params "noParams" }}, params {{ .Params }}{{ end }}) ({{ if .Result }}result {{ .Result }}, {{ end }}err error) {
+
+	{{ if .Result }}result{{ else }}_{{ end }}, err = {{ .Var }}.Call(ctx, svc.Client, {{ if eq .Params "noParams" }}noParams{}{{ else }}params{{ end }})
+{{- if .MapsErrors }}
+	err = mapErrors(err, {{ if .Errors }}map[int]errorDecoder{
+{{- range .Errors }}
+		{{ .Code }}: decodeError[{{ .Type }}],
+{{- end }}
+	}{{ else }}nil{{ end }}, {{ if .Default }}decodeError[{{ .Default }}]{{ else }}nil{{ end }})
+{{- end }}
+	return
+}
+{{ if .Pagination }}
+var {{ .PaginationVar }} = giant.Pagination{
+	Pager: {{ .Pagination.Pager }},
+{{- if .Pagination.ItemsKey }}
+	ItemsKey: "{{ .Pagination.ItemsKey }}",
+{{- end }}
+}
+
+// {{ .Name }}All iterates over items on all pages of {{ .Name }}.
+func (svc *{{ $.Svc }}) {{ .Name }}All(ctx context.Context{{ if ne .Params "noParams" }}, params {{ .Params }}{{ end }}) iter.Seq2[{{ .Pagination.Item }}, error] {
+
+	path, err := {{ .Var }}.Resolve({{ if eq .Params "noParams" }}noParams{}{{ else }}params{{ end }})
+	if err != nil {
+		return func(yield func({{ .Pagination.Item }}, error) bool) {
+			var item {{ .Pagination.Item }}
+			yield(item, err)
+		}
+	}
+
+	return giant.Paginate[{{ .Pagination.Item }}](ctx, svc.Client, path, {{ .PaginationVar }})
+}
+{{ end }}
+{{- end }}
+{{- if .NoParams }}
+// noParams is the query of endpoints without params or body.
+type noParams struct{}
+
+// RequestBody implements giant.RequestBodier.
+func (params noParams) RequestBody() any {
+	return nil
+}
+{{ end }}
+{{- if .MapsErrors }}
+// ResponseError is returned for documented error responses, with the body decoded.
+type ResponseError[T any] struct {
+	Code int
+	Body T
+	Err  error
+}
+
+// Error implements the error interface.
+func (err *ResponseError[T]) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ResponseError[T]) Unwrap() error {
+	return err.Err
+}
+
+type errorDecoder func(err error, code int, body []byte) error
+
+// mapErrors decodes the body of a status error per its code, or with fallback when not listed.
+func mapErrors(err error, decoders map[int]errorDecoder, fallback errorDecoder) error {
+
+	var statusErr *statusrt.StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	decoder, ok := decoders[statusErr.Code]
+	if !ok {
+		decoder = fallback
+	}
+	if decoder == nil {
+		return err
+	}
+
+	return decoder(err, statusErr.Code, statusErr.Body)
+}
+
+func decodeError[T any](err error, code int, body []byte) error {
+
+	rspErr := &ResponseError[T]{Code: code, Err: err}
+	if json.Unmarshal(body, &rspErr.Body) != nil {
+		return err
+	}
+
+	return rspErr
+}
+{{ end -}}
+`))
@@ -0,0 +1,160 @@
+package gen
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGen(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gen Suite")
+}
+
+var _ = Describe("Gen", func() {
+
+	Describe("generating a client", func() {
+
+		var (
+			data []byte
+			code []byte
+			err  error
+		)
+
+		JustBeforeEach(func() {
+			var spec *Spec
+			spec, err = Load(data)
+			if err != nil {
+				return
+			}
+
+			code, err = Generate(spec, Options{Package: "petstore"})
+		})
+
+		When("spec is yaml", func() {
+			BeforeEach(func() {
+				data, err = os.ReadFile("testdata/petstore.yaml")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("generates types, endpoints and methods", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				// regenerate with:
+				// go run ./cmd/giant-gen -spec gen/testdata/petstore.yaml -pkg petstore -out gen/testdata/petstore.go.golden
+
+				golden, err := os.ReadFile("testdata/petstore.go.golden")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(code)).To(Equal(string(golden)))
+			})
+		})
+
+		When("spec is json", func() {
+			BeforeEach(func() {
+				data = []byte(`{
+				  "openapi": "3.1.0",
+				  "info": {"title": "Boxworld", "version": "1"},
+				  "paths": {
+				    "/boxes/{id}": {
+				      "get": {
+				        "operationId": "get_box",
+				        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+				        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {
+				          "type": "object",
+				          "required": ["material"],
+				          "properties": {"material": {"type": ["string", "null"]}, "size": {"type": "number"}}
+				        }}}}}
+				      }
+				    }
+				  }
+				}`)
+			})
+
+			It("generates from it", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(code)).To(ContainSubstring("type GetBoxResult struct {\n\tMaterial string  `json:\"material\"`\n\tSize     float64 `json:\"size,omitempty\"`\n}"))
+				Expect(string(code)).To(ContainSubstring("func (svc *Svc) GetBox(ctx context.Context, params GetBoxParams) (result GetBoxResult, err error) {"))
+				Expect(string(code)).ToNot(ContainSubstring("mapErrors"))
+			})
+		})
+
+		When("request body is optional", func() {
+			BeforeEach(func() {
+				data = []byte(`
+openapi: 3.0.0
+paths:
+  /boxes/{id}:
+    patch:
+      operationId: patchBox
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                material:
+                  type: string
+      responses:
+        '204':
+          description: patched
+`)
+			})
+
+			It("generates a pointer, sent only when set", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(code)).To(ContainSubstring("\tBody *PatchBoxBody\n"))
+				Expect(string(code)).To(ContainSubstring("if params.Body == nil {\n\t\treturn nil\n\t}\n\treturn params.Body\n"))
+			})
+		})
+
+		When("a ref is unresolved", func() {
+			BeforeEach(func() {
+				data = []byte(`
+openapi: 3.0.0
+paths:
+  /boxes:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Box'
+`)
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("unresolved schema ref: #/components/schemas/Box")))
+			})
+		})
+
+		When("spec is not openapi 3", func() {
+			BeforeEach(func() {
+				data = []byte(`swagger: "2.0"`)
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(`unsupported openapi version: ""`))
+			})
+		})
+	})
+
+	Describe("converting names", func() {
+
+		It("makes exported identifiers", func() {
+			Expect(goName("pet_id")).To(Equal("PetId"))
+			Expect(goName("listPets")).To(Equal("ListPets"))
+			Expect(goName("get /pets/{pet_id}")).To(Equal("GetPetsPetId"))
+			Expect(goName("2fa")).To(Equal("X2fa"))
+			Expect(goName("-")).To(Equal("X"))
+		})
+	})
+})
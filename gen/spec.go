@@ -0,0 +1,278 @@
+package gen
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec represents the parts of an OpenAPI 3 document used in generating a client.
+type Spec struct {
+	OpenApi    string                `yaml:"openapi"`
+	Info       Info                  `yaml:"info"`
+	Paths      OrderedMap[*PathItem] `yaml:"paths"`
+	Components Components            `yaml:"components"`
+}
+
+// Info represents spec metadata.
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// Components represents reusable spec objects, referred to with $ref.
+type Components struct {
+	Schemas       OrderedMap[*Schema]   `yaml:"schemas"`
+	Parameters    map[string]*Parameter `yaml:"parameters"`
+	RequestBodies map[string]*Body      `yaml:"requestBodies"`
+	Responses     map[string]*Response  `yaml:"responses"`
+}
+
+// PathItem represents the operations available on a path.
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Patch      *Operation   `yaml:"patch"`
+}
+
+// Operation represents a single api operation on a path.
+type Operation struct {
+	OperationId string                `yaml:"operationId"`
+	Summary     string                `yaml:"summary"`
+	Description string                `yaml:"description"`
+	Parameters  []*Parameter          `yaml:"parameters"`
+	RequestBody *Body                 `yaml:"requestBody"`
+	Responses   OrderedMap[*Response] `yaml:"responses"`
+	Pagination  *Pagination           `yaml:"x-pagination"`
+}
+
+// Parameter represents an operation parameter.
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Style       string  `yaml:"style"`
+	Explode     *bool   `yaml:"explode"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// Body represents a request body.
+type Body struct {
+	Ref      string               `yaml:"$ref"`
+	Required bool                 `yaml:"required"`
+	Content  map[string]MediaType `yaml:"content"`
+}
+
+// Response represents an operation response.
+type Response struct {
+	Ref         string               `yaml:"$ref"`
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content"`
+}
+
+// MediaType represents the schema of a body for a content type.
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Schema represents a json schema, as far as it maps onto Go types.
+type Schema struct {
+	Ref                  string              `yaml:"$ref"`
+	Type                 Type                `yaml:"type"`
+	Format               string              `yaml:"format"`
+	Description          string              `yaml:"description"`
+	Properties           OrderedMap[*Schema] `yaml:"properties"`
+	Required             []string            `yaml:"required"`
+	Items                *Schema             `yaml:"items"`
+	Enum                 []any               `yaml:"enum"`
+	AllOf                []*Schema           `yaml:"allOf"`
+	AdditionalProperties any                 `yaml:"additionalProperties"`
+}
+
+// Type is a schema type, the first that's not null when given as a list in OpenAPI 3.1.
+type Type string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (typ *Type) UnmarshalYAML(node *yaml.Node) (err error) {
+
+	if node.Kind != yaml.SequenceNode {
+		err = node.Decode((*string)(typ))
+		return
+	}
+
+	var types []string
+	err = node.Decode(&types)
+	if err != nil {
+		return
+	}
+
+	for _, each := range types {
+		if each != "null" {
+			*typ = Type(each)
+			return
+		}
+	}
+
+	return
+}
+
+// Pagination is the x-pagination extension to operations, hinting at how pages are followed.
+// Style is one of link, cursor, offset or page.
+type Pagination struct {
+	Style      string `yaml:"style"`
+	ItemsKey   string `yaml:"items"`
+	Field      string `yaml:"field"`
+	Param      string `yaml:"param"`
+	LimitParam string `yaml:"limit_param"`
+	Limit      int    `yaml:"limit"`
+}
+
+// OrderedMap is a yaml map keeping the order of its keys.
+type OrderedMap[T any] struct {
+	Keys   []string
+	Values map[string]T
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (ordered *OrderedMap[T]) UnmarshalYAML(node *yaml.Node) (err error) {
+
+	if node.Kind != yaml.MappingNode {
+		err = errors.Errorf("expected mapping at line %d", node.Line)
+		return
+	}
+
+	ordered.Values = map[string]T{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+
+		key := node.Content[i].Value
+
+		var value T
+		err = node.Content[i+1].Decode(&value)
+		if err != nil {
+			return
+		}
+
+		if _, ok := ordered.Values[key]; !ok {
+			ordered.Keys = append(ordered.Keys, key)
+		}
+		ordered.Values[key] = value
+	}
+
+	return
+}
+
+// Load parses a spec from yaml or json.
+func Load(data []byte) (spec *Spec, err error) {
+
+	spec = &Spec{}
+	err = yaml.Unmarshal(data, spec)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to parse spec")
+		return
+	}
+
+	if !strings.HasPrefix(spec.OpenApi, "3.") {
+		err = errors.Errorf("unsupported openapi version: %q", spec.OpenApi)
+		return
+	}
+
+	return
+}
+
+// unexported
+
+const (
+	schemaPrefix    string = "#/components/schemas/"
+	parameterPrefix string = "#/components/parameters/"
+	bodyPrefix      string = "#/components/requestBodies/"
+	responsePrefix  string = "#/components/responses/"
+)
+
+func (spec *Spec) parameter(param *Parameter) (resolved *Parameter, err error) {
+
+	if param.Ref == "" {
+		resolved = param
+		return
+	}
+
+	resolved, ok := spec.Components.Parameters[strings.TrimPrefix(param.Ref, parameterPrefix)]
+	if !ok || !strings.HasPrefix(param.Ref, parameterPrefix) {
+		err = errors.Errorf("unresolved parameter ref: %s", param.Ref)
+	}
+	return
+}
+
+func (spec *Spec) body(body *Body) (resolved *Body, err error) {
+
+	if body.Ref == "" {
+		resolved = body
+		return
+	}
+
+	resolved, ok := spec.Components.RequestBodies[strings.TrimPrefix(body.Ref, bodyPrefix)]
+	if !ok || !strings.HasPrefix(body.Ref, bodyPrefix) {
+		err = errors.Errorf("unresolved request body ref: %s", body.Ref)
+	}
+	return
+}
+
+func (spec *Spec) response(response *Response) (resolved *Response, err error) {
+
+	if response.Ref == "" {
+		resolved = response
+		return
+	}
+
+	resolved, ok := spec.Components.Responses[strings.TrimPrefix(response.Ref, responsePrefix)]
+	if !ok || !strings.HasPrefix(response.Ref, responsePrefix) {
+		err = errors.Errorf("unresolved response ref: %s", response.Ref)
+	}
+	return
+}
+
+// schema follows refs to a component schema, returning its name as well.
+
+func (spec *Spec) schema(schema *Schema) (name string, resolved *Schema, err error) {
+
+	resolved = schema
+	for hops := 0; resolved != nil && resolved.Ref != ""; hops++ {
+
+		ref := resolved.Ref
+		if hops > len(spec.Components.Schemas.Keys) {
+			err = errors.Errorf("circular schema ref: %s", ref)
+			return
+		}
+
+		name = strings.TrimPrefix(ref, schemaPrefix)
+
+		var ok bool
+		resolved, ok = spec.Components.Schemas.Values[name]
+		if !ok || !strings.HasPrefix(ref, schemaPrefix) {
+			err = errors.Errorf("unresolved schema ref: %s", ref)
+			return
+		}
+	}
+
+	return
+}
+
+// jsonSchema returns the schema of json content, nil if none.
+
+func jsonSchema(content map[string]MediaType) *Schema {
+
+	for _, ctype := range slices.Sorted(maps.Keys(content)) {
+		if ctype == "application/json" || strings.HasSuffix(ctype, "+json") {
+			return content[ctype].Schema
+		}
+	}
+
+	return nil
+}
@@ -8,19 +8,23 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/clarktrimble/giant/basicrt"
+	"github.com/clarktrimble/giant/compressrt"
 	"github.com/clarktrimble/giant/logger"
 	"github.com/clarktrimble/giant/logrt"
 	"github.com/clarktrimble/giant/oauth2rt"
+	"github.com/clarktrimble/giant/propagate"
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/route"
 	"github.com/clarktrimble/giant/statusrt"
 	"github.com/clarktrimble/launch"
 	"github.com/pkg/errors"
@@ -52,14 +56,23 @@ type Config struct {
 	//KeyHeader string `json:"api_key_header,omitempty" desc:"Todo"`
 	//ApiKey    Redact `json:"api_key_value,omitempty" desc:"Todo and sneak into redact headers"`
 	// Todo: orrrrrrrr a giant client helper in bfc would work?
+	// RequestIdHeader is the header request ids are sent upstream with in NewWithTrippers, X-Request-Id when blank.
+	RequestIdHeader string `json:"request_id_header,omitempty" desc:"header to send request id upstream with" default:"X-Request-Id"`
 	// RedactHeaders are headers to be redacted from logging in NewWithTrippers.
 	RedactHeaders []string `json:"redact_headers,omitempty" desc:"headers to redact from request logging"`
 	// SkipBody when true request and response bodies are not logged in NewWithTrippers..
 	SkipBody bool `json:"skip_body" desc:"skip logging of body for request and response" default:"false"`
+	// Compress when true gzips larger request bodies and decodes compressed responses in NewWithTrippers.
+	Compress bool `json:"compress" desc:"gzip request bodies and decode compressed responses" default:"false"`
 	// UnixSocket
 	UnixSocket string `json:"unix_socket,omitempty" desc:"unix socket"`
 	// OAuth2 is for OAuth2 client credentials in NewWithTrippers.
 	OAuth2 *OAuth2Config `json:"oauth2,omitempty" desc:"OAuth2 client credentials config"`
+	// Fixtures when set serves requests from files in the directory, in place of the network.
+	// See FixtureHandler for layout.
+	Fixtures string `json:"fixtures,omitempty" desc:"directory of fixtures to serve in place of the network"`
+	// Handler when set serves requests in-process, in place of the network.
+	Handler http.Handler `json:"-" ignored:"true"`
 }
 
 // OAuth2Config represents OAuth2 client credentials configuration.
@@ -73,6 +86,8 @@ type OAuth2Config struct {
 	ClientID string `json:"client_id" desc:"OAuth2 client ID"`
 	// ClientSecret is the OAuth2 client secret.
 	ClientSecret launch.Redact `json:"client_secret" desc:"OAuth2 client secret or path to secret file"`
+	// Form sends the token request url-encoded rather than as json.
+	Form bool `json:"form" desc:"send token request form-encoded" default:"false"`
 }
 
 // Giant represents an http client
@@ -83,6 +98,14 @@ type Giant struct {
 	BaseUri string
 	// Headers are set when making a request
 	Headers map[string]string
+	// Codec encodes and decodes objects, json when nil
+	Codec Codec
+	// Codecs are additionally accepted, and chosen among by response Content-Type when set
+	Codecs []Codec
+	// Strictness is the checking of response Content-Type before decoding, lenient by default
+	Strictness Strictness
+	// Routes derives route templates from request paths when set, for metrics, logging and tracing
+	Routes *route.Matcher
 }
 
 // New constructs a new client from Config
@@ -93,7 +116,7 @@ func (cfg *Config) New() *Giant {
 		ciphers = cfg.Ciphers
 	}
 
-	transport := &http.Transport{
+	var transport http.RoundTripper = &http.Transport{
 		Dial:                (&net.Dialer{Timeout: cfg.TimeoutShort}).Dial,
 		TLSHandshakeTimeout: cfg.TimeoutShort,
 		TLSClientConfig: &tls.Config{
@@ -111,6 +134,14 @@ func (cfg *Config) New() *Giant {
 		}
 	}
 
+	if cfg.Fixtures != "" {
+		transport = NewHandlerTransport(NewFixtureHandler(cfg.Fixtures))
+	}
+
+	if cfg.Handler != nil {
+		transport = NewHandlerTransport(cfg.Handler)
+	}
+
 	// copy header cfg pairs into map ignoring odd count
 
 	hdrs := map[string]string{}
@@ -133,13 +164,19 @@ func (cfg *Config) New() *Giant {
 	}
 }
 
-// NewWithTrippers is a convenience method that adds StatusRt and Logrt after creating a client.
+// NewWithTrippers is a convenience method that adds StatusRt, Logrt and BaggageRt after creating a client.
+// If Compress is set in Config CompressRt is added as well.
 // If OAuth2 is defined in Config OAuth2Rt is added as well.
 // If User and Pass are defined in Config BasicRt is added as well.
 func (cfg *Config) NewWithTrippers(lgr logger.Logger) (giant *Giant) {
 
 	giant = cfg.New()
 
+	// compression goes nearest the wire so others see plain bodies
+	if cfg.Compress {
+		giant.Use(compressrt.New(0, 0))
+	}
+
 	// OAuth2 goes first (innermost) so auth header is set before logging/status
 	if cfg.OAuth2 != nil && cfg.OAuth2.ClientID != "" {
 		baseUri := cfg.OAuth2.BaseUri
@@ -151,11 +188,14 @@ func (cfg *Config) NewWithTrippers(lgr logger.Logger) (giant *Giant) {
 			TokenPath:    cfg.OAuth2.TokenPath,
 			ClientID:     cfg.OAuth2.ClientID,
 			ClientSecret: string(cfg.OAuth2.ClientSecret),
+			Form:         cfg.OAuth2.Form,
 			Logger:       lgr,
 		})
 	}
 
+	giant.Use(&propagate.BaggageRt{})
 	giant.Use(&statusrt.StatusRt{})
+	giant.Use(reqid.NewRt(cfg.RequestIdHeader))
 	giant.Use(logrt.New(lgr, cfg.RedactHeaders, cfg.SkipBody))
 
 	if cfg.User != "" && cfg.Pass != "" {
@@ -179,17 +219,46 @@ type Request struct {
 	Method string
 	// Path is appended to BaseUri when making a request
 	// (leading and trailing slashes recommended here, convention for sanity!)
+	// Placeholders such as {id} are filled from Params.
 	Path string
+	// Params fill Path placeholders, each escaped and all required
+	Params map[string]string
+	// Query is encoded onto Path, after any query already there
+	Query url.Values
 	// Body is read from when making a request
 	Body io.Reader
 	// Headers are set when making a request
 	Headers map[string]string
+	// Route is the template of Path, ex: /users/{id}, taken from a Path with placeholders
+	// or derived with Routes when blank
+	Route string
 }
 
 // Send sends a request
 // leaving read/close of response body to caller
+// A request id is added to context if not already there, for logging and propagation,
+// and is included in errors.
+// The route template is added to context as well, when given or derived.
 func (giant *Giant) Send(ctx context.Context, rq Request) (response *http.Response, err error) {
 
+	ctx, id := reqid.Ensure(ctx)
+
+	path, template, err := expandPath(rq.Path, rq.Params)
+	if err != nil {
+		return
+	}
+	rq.Path = path
+
+	if rq.Route == "" {
+		rq.Route = template
+	}
+	if rq.Route == "" && giant.Routes != nil {
+		rq.Route = giant.Routes.Match(rq.Path)
+	}
+	if rq.Route != "" {
+		ctx = route.With(ctx, rq.Route)
+	}
+
 	if rq.Headers == nil {
 		rq.Headers = map[string]string{}
 	}
@@ -201,50 +270,34 @@ func (giant *Giant) Send(ctx context.Context, rq Request) (response *http.Respon
 	}
 
 	response, err = giant.Client.Do(request)
-	err = errors.Wrapf(err, "http %s request to %s %s failed", rq.Method, giant.BaseUri, rq.Path)
+	err = errors.Wrapf(err, "http %s request to %s %s failed with request_id %s", rq.Method, giant.BaseUri, rq.Path, id)
 	return
 }
 
 // SendJson constructs a request, sends and receives json closing the response body
 func (giant *Giant) SendJson(ctx context.Context, method, path string, body io.Reader) (data []byte, err error) {
 
-	rq := Request{
-		Method: method,
-		Path:   path,
-		Body:   body,
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-			"Accept":       "application/json",
-		},
-	}
-
-	response, err := giant.Send(ctx, rq)
-	if err != nil {
-		return
-	}
-	defer response.Body.Close()
-
-	data, err = io.ReadAll(response.Body)
+	_, data, err = giant.sendJson(ctx, method, path, body)
 	return
 }
 
-// SendObject marshalls the object to be sent, unmarshalls the response body, and calls SendJson
+// SendObject encodes the object to be sent, sends it and decodes the response body into rcvObj,
+// with the codec from context or client, json by default.
 func (giant *Giant) SendObject(ctx context.Context, method, path string, sndObj, rcvObj any) (err error) {
 
-	sndData, err := marshal(sndObj)
+	codec := giant.codec(ctx)
+
+	sndData, err := encode(codec, sndObj)
 	if err != nil {
 		return
 	}
 
-	rcvData, err := giant.SendJson(ctx, method, path, bytes.NewBuffer(sndData))
+	response, rcvData, err := giant.sendData(ctx, method, path, bytes.NewBuffer(sndData), codec.ContentType(), giant.accept(codec))
 	if err != nil {
 		return
 	}
 
-	if rcvObj != nil {
-		err = json.Unmarshal(rcvData, &rcvObj)
-		err = errors.Wrapf(err, "failed to decode response into %#v", rcvObj)
-	}
+	err = giant.decodeResponse(codec, response, rcvData, rcvObj)
 	return
 }
 
@@ -263,15 +316,34 @@ type tripper interface {
 	Wrap(next http.RoundTripper)
 }
 
-func marshal(obj any) (data []byte, err error) {
+func (giant *Giant) sendJson(ctx context.Context, method, path string, body io.Reader) (response *http.Response, data []byte, err error) {
 
-	data = []byte{}
-	if obj == nil {
+	response, data, err = giant.sendData(ctx, method, path, body, "application/json", "application/json")
+	return
+}
+
+// sendData sends a request and reads the response body, closing it.
+
+func (giant *Giant) sendData(ctx context.Context, method, path string, body io.Reader, contentType, accept string) (response *http.Response, data []byte, err error) {
+
+	rq := Request{
+		Method: method,
+		Path:   path,
+		Body:   body,
+		Headers: map[string]string{
+			"Content-Type": contentType,
+			"Accept":       accept,
+		},
+	}
+
+	response, err = giant.Send(ctx, rq)
+	if err != nil {
 		return
 	}
+	defer response.Body.Close()
 
-	data, err = json.Marshal(obj)
-	err = errors.Wrapf(err, "failed to marshal object: %#v", obj)
+	data, err = io.ReadAll(response.Body)
+	err = errors.Wrapf(err, "failed to read response from %s %s", method, path)
 	return
 }
 
@@ -283,6 +355,13 @@ func (rq Request) httpRequest(ctx context.Context, baseUri string) (request *htt
 		return
 	}
 
+	if len(rq.Query) > 0 {
+		if uri.RawQuery != "" {
+			uri.RawQuery += "&"
+		}
+		uri.RawQuery += rq.Query.Encode()
+	}
+
 	request, err = http.NewRequestWithContext(ctx, rq.Method, uri.String(), rq.Body)
 	if err != nil {
 		err = errors.Wrapf(err, "unable to create %s request to %s %s", rq.Method, baseUri, rq.Path)
@@ -296,6 +375,48 @@ func (rq Request) httpRequest(ctx context.Context, baseUri string) (request *htt
 	return
 }
 
+// resolvePath resolves a reference found in a response, such as a link or location,
+// against the request and returns it as a path relative to base uri.
+// The reference must share scheme and host, port included, with base uri and fall under its path.
+
+func resolvePath(baseUri, path, ref string) (resolved string, err error) {
+
+	baseUrl, err := url.Parse(baseUri)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to parse base uri: %s", baseUri)
+		return
+	}
+
+	base, err := url.Parse(baseUri + path)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to parse uri from %s %s", baseUri, path)
+		return
+	}
+
+	refUri, err := url.Parse(ref)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to parse reference: %s", ref)
+		return
+	}
+
+	uri := base.ResolveReference(refUri)
+	basePath := strings.TrimSuffix(baseUrl.EscapedPath(), "/")
+	refPath := uri.EscapedPath()
+
+	if uri.Scheme != baseUrl.Scheme || !strings.EqualFold(uri.Host, baseUrl.Host) ||
+		(refPath != basePath && !strings.HasPrefix(refPath, basePath+"/")) {
+
+		err = errors.Errorf("reference %s is not under base uri %s", uri, baseUri)
+		return
+	}
+
+	resolved = strings.TrimPrefix(refPath, basePath)
+	if uri.RawQuery != "" {
+		resolved += "?" + uri.RawQuery
+	}
+	return
+}
+
 func noRedirect(request *http.Request, via []*http.Request) error {
 	// do not want posts redirected to a get
 	// a-and generally expect to get it right, yeah
@@ -14,6 +14,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/clarktrimble/giant/logrt"
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/route"
 	"github.com/clarktrimble/launch"
 )
 
@@ -278,9 +280,140 @@ var _ = Describe("Giant", func() {
 				})
 			})
 
+			When("request fails", func() {
+				BeforeEach(func() {
+					rq = Request{}
+					ctx = reqid.With(ctx, "abc1234")
+					ts.Server.Close()
+				})
+				It("includes request id in error", func() {
+					Expect(err).To(MatchError(ContainSubstring("failed with request_id abc1234")))
+				})
+			})
+
+			Describe("path params", func() {
+				var (
+					rrt *routeRt
+				)
+
+				BeforeEach(func() {
+					rrt = &routeRt{}
+					gnt.Client.Transport = http.DefaultTransport
+					gnt.Use(rrt)
+					rq = Request{
+						Path:   "/users/{id}/files/{name}?full=true",
+						Params: map[string]string{"id": "a/b", "name": "my file#1"},
+					}
+				})
+
+				When("all placeholders are filled", func() {
+					It("escapes each and carries the template as route", func() {
+						Expect(err).ToNot(HaveOccurred())
+						Expect(ts.Path).To(Equal("/users/a%2Fb/files/my%20file%231?full=true"))
+						Expect(rrt.Route).To(Equal("/users/{id}/files/{name}"))
+					})
+				})
+
+				When("a placeholder is not filled", func() {
+					BeforeEach(func() {
+						delete(rq.Params, "name")
+					})
+					It("returns an error without sending", func() {
+						Expect(err).To(MatchError(ContainSubstring(`path param "name" not given`)))
+						Expect(rrt.Route).To(BeEmpty())
+					})
+				})
+
+				When("a param would traverse", func() {
+					BeforeEach(func() {
+						rq.Params["id"] = ".."
+					})
+					It("returns an error without sending", func() {
+						Expect(err).To(MatchError(ContainSubstring(`path param "id" may not be ".."`)))
+						Expect(rrt.Route).To(BeEmpty())
+					})
+				})
+
+				When("a param has no placeholder", func() {
+					BeforeEach(func() {
+						rq.Params["nmae"] = "typo"
+					})
+					It("returns an error without sending", func() {
+						Expect(err).To(MatchError(ContainSubstring(`path param "nmae" has no placeholder`)))
+						Expect(rrt.Route).To(BeEmpty())
+					})
+				})
+			})
+
+			Describe("route templating", func() {
+				var (
+					rrt *routeRt
+				)
+
+				BeforeEach(func() {
+					rrt = &routeRt{}
+					gnt.Client.Transport = http.DefaultTransport
+					gnt.Use(rrt)
+					rq = Request{Path: "/users/123/orders/456?full=true"}
+				})
+
+				When("route is given", func() {
+					BeforeEach(func() {
+						rq.Route = "/users/{id}/orders/{oid}"
+					})
+					It("carries it in context", func() {
+						Expect(err).ToNot(HaveOccurred())
+						Expect(rrt.Route).To(Equal("/users/{id}/orders/{oid}"))
+					})
+				})
+
+				When("routes are matched", func() {
+					BeforeEach(func() {
+						gnt.Routes = route.NewMatcher("/users/{id}", "/users/{uid}/orders/{oid}")
+					})
+					It("carries the matching template in context", func() {
+						Expect(err).ToNot(HaveOccurred())
+						Expect(rrt.Route).To(Equal("/users/{uid}/orders/{oid}"))
+					})
+				})
+
+				When("no route is given or matched", func() {
+					It("falls back to the path", func() {
+						Expect(err).ToNot(HaveOccurred())
+						Expect(rrt.Route).To(Equal("/users/123/orders/456"))
+					})
+				})
+			})
+
 		})
 	})
 
+	Describe("resolving references", func() {
+
+		It("returns paths under base uri", func() {
+			path, err := resolvePath("http://api.example.com/v1", "/jobs/", "1?full=true")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/jobs/1?full=true"))
+
+			path, err = resolvePath("http://api.example.com/v1", "/jobs/", "http://api.example.com/v1/jobs/2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/jobs/2"))
+		})
+
+		It("rejects lookalike hosts, other ports and paths outside base", func() {
+			_, err := resolvePath("http://api.example.com", "/jobs/", "http://api.example.com.evil.net/steal")
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+
+			_, err = resolvePath("http://api.example.com:80", "/jobs/", "http://api.example.com:8080/x")
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+
+			_, err = resolvePath("https://api.example.com", "/jobs/", "http://api.example.com/jobs/1")
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+
+			_, err = resolvePath("http://api.example.com/v1", "/jobs/", "/v10/jobs/1")
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+		})
+	})
 })
 
 // help
@@ -289,6 +422,21 @@ type foo struct {
 	Data string `json:"data"`
 }
 
+type routeRt struct {
+	Route string
+	next  http.RoundTripper
+}
+
+func (rt *routeRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+func (rt *routeRt) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	rt.Route = route.Of(request)
+	return rt.next.RoundTrip(request)
+}
+
 type testServer struct {
 	Server        *httptest.Server
 	ContentHeader string
@@ -313,6 +461,7 @@ func newTestServer(responseBody string) (ts *testServer) {
 		ts.Path = request.RequestURI
 		ts.Body = string(body)
 
+		writer.Header().Set("Content-Type", "application/json")
 		fmt.Fprint(writer, responseBody)
 	}))
 
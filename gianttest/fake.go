@@ -0,0 +1,369 @@
+// Package gianttest provides fakes for testing code built on giant.
+package gianttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/clarktrimble/giant"
+	"github.com/clarktrimble/giant/route"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FakeUri is the base uri of clients returned by FakeClient.Giant.
+	FakeUri string = "http://gianttest.fake"
+)
+
+// FakeClient stands in for giant in service layers depending on a narrow interface,
+// such as one with SendObject, returning canned objects or errors from stubs and recording calls.
+// It's an http.RoundTripper as well, for services taking a *giant.Giant.
+type FakeClient struct {
+	stubs []*Stub
+	calls []Call
+	mu    sync.Mutex
+}
+
+// Call is a recorded call.
+type Call struct {
+	Method string
+	Path   string
+	// Body is the object sent, or data when sent raw.
+	Body any
+	// Stub is the matched stub, nil when none.
+	Stub *Stub
+}
+
+// Stub matches calls and returns a canned result.
+type Stub struct {
+	method  string
+	path    string
+	matcher *route.Matcher
+	body    any
+	hasBody bool
+	result  any
+	status  int
+	err     error
+	times   int
+	calls   int
+}
+
+// On adds a stub for a method and path, the path may have placeholders, ex: /users/{id}.
+// Query is ignored unless the path has one, in which case it must match exactly.
+// Stubs are tried in the order they were added.
+func (fake *FakeClient) On(method, path string) (stub *Stub) {
+
+	stub = &Stub{
+		method:  method,
+		path:    path,
+		matcher: route.NewMatcher(path),
+		status:  http.StatusOK,
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	fake.stubs = append(fake.stubs, stub)
+	return
+}
+
+// WithBody limits the stub to calls with a body equal to obj once both are encoded as json.
+func (stub *Stub) WithBody(obj any) *Stub {
+
+	stub.body = obj
+	stub.hasBody = true
+	return stub
+}
+
+// Return sets the object decoded into rcvObj, copied directly when assignable and via json otherwise.
+// A string or []byte is taken to be json.
+func (stub *Stub) Return(obj any) *Stub {
+
+	stub.result = obj
+	return stub
+}
+
+// Status sets the status of responses, 200 by default, when used as a round tripper.
+func (stub *Stub) Status(code int) *Stub {
+
+	stub.status = code
+	return stub
+}
+
+// ReturnError sets the error returned.
+func (stub *Stub) ReturnError(err error) *Stub {
+
+	stub.err = err
+	return stub
+}
+
+// Times limits the stub to matching n calls, unlimited when zero.
+func (stub *Stub) Times(n int) *Stub {
+
+	stub.times = n
+	return stub
+}
+
+// Calls returns recorded calls.
+func (fake *FakeClient) Calls() []Call {
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	return append([]Call{}, fake.calls...)
+}
+
+// CallsTo returns recorded calls matching method and path as a stub would.
+func (fake *FakeClient) CallsTo(method, path string) (calls []Call) {
+
+	probe := &Stub{method: method, path: path, matcher: route.NewMatcher(path)}
+	for _, call := range fake.Calls() {
+		if probe.matchPath(call.Method, call.Path) {
+			calls = append(calls, call)
+		}
+	}
+
+	return
+}
+
+// Unmatched returns recorded calls no stub matched.
+func (fake *FakeClient) Unmatched() (calls []Call) {
+
+	for _, call := range fake.Calls() {
+		if call.Stub == nil {
+			calls = append(calls, call)
+		}
+	}
+
+	return
+}
+
+// SendObject returns the result of the first matching stub, decoded into rcvObj.
+func (fake *FakeClient) SendObject(ctx context.Context, method, path string, sndObj, rcvObj any) (err error) {
+
+	stub, err := fake.match(method, path, sndObj)
+	if err != nil {
+		return
+	}
+
+	err = stub.decode(rcvObj)
+	return
+}
+
+// SendJson returns the result of the first matching stub, encoded as json.
+func (fake *FakeClient) SendJson(ctx context.Context, method, path string, body io.Reader) (data []byte, err error) {
+
+	sent, err := read(body)
+	if err != nil {
+		return
+	}
+
+	stub, err := fake.match(method, path, sent)
+	if err != nil {
+		return
+	}
+
+	data, err = stub.encode()
+	return
+}
+
+// Giant returns a client using the fake as transport.
+func (fake *FakeClient) Giant() *giant.Giant {
+
+	return &giant.Giant{
+		Client:  http.Client{Transport: fake},
+		BaseUri: FakeUri,
+	}
+}
+
+// RoundTrip returns a response with the result of the first matching stub encoded as json.
+// Errors set with ReturnError are returned as is, as from a failed transport.
+func (fake *FakeClient) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	var sent []byte
+	if request.Body != nil {
+		sent, err = read(request.Body)
+		if err != nil {
+			return
+		}
+	}
+
+	stub, err := fake.match(request.Method, request.URL.RequestURI(), sent)
+	if err != nil {
+		return
+	}
+
+	data, err := stub.encode()
+	if err != nil {
+		return
+	}
+
+	response = &http.Response{
+		StatusCode: stub.status,
+		Status:     fmt.Sprintf("%d %s", stub.status, http.StatusText(stub.status)),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    request,
+	}
+	return
+}
+
+// unexported
+
+// match records the call and returns the first matching stub's error if any.
+
+func (fake *FakeClient) match(method, path string, body any) (stub *Stub, err error) {
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	call := Call{Method: method, Path: path, Body: body}
+	defer func() {
+		fake.calls = append(fake.calls, call)
+	}()
+
+	for _, candidate := range fake.stubs {
+		if candidate.match(method, path, body) {
+			stub = candidate
+			break
+		}
+	}
+
+	if stub == nil {
+		err = errors.Errorf("gianttest: no stub matches %s %s", method, path)
+		return
+	}
+
+	stub.calls++
+	call.Stub = stub
+
+	err = stub.err
+	return
+}
+
+func (stub *Stub) match(method, path string, body any) bool {
+
+	if stub.times > 0 && stub.calls >= stub.times {
+		return false
+	}
+
+	if !stub.matchPath(method, path) {
+		return false
+	}
+
+	return !stub.hasBody || equalJson(stub.body, body)
+}
+
+func (stub *Stub) matchPath(method, path string) bool {
+
+	if method != stub.method {
+		return false
+	}
+
+	if strings.Contains(stub.path, "?") {
+		return path == stub.path
+	}
+
+	return stub.matcher.Match(path) != ""
+}
+
+func (stub *Stub) encode() (data []byte, err error) {
+
+	switch result := stub.result.(type) {
+	case nil:
+		return
+	case []byte:
+		data = result
+	case string:
+		data = []byte(result)
+	default:
+		data, err = json.Marshal(result)
+		err = errors.Wrapf(err, "gianttest: failed to encode result for %s %s", stub.method, stub.path)
+	}
+
+	return
+}
+
+func (stub *Stub) decode(rcvObj any) (err error) {
+
+	if stub.result == nil || rcvObj == nil {
+		return
+	}
+
+	dst := reflect.ValueOf(rcvObj)
+	src := reflect.ValueOf(stub.result)
+	if dst.Kind() == reflect.Pointer && !dst.IsNil() {
+
+		if src.Type().AssignableTo(dst.Elem().Type()) {
+			dst.Elem().Set(src)
+			return
+		}
+		if src.Kind() == reflect.Pointer && src.Type().AssignableTo(dst.Type()) {
+			dst.Elem().Set(src.Elem())
+			return
+		}
+	}
+
+	data, err := stub.encode()
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, rcvObj)
+	err = errors.Wrapf(err, "gianttest: failed to decode result for %s %s", stub.method, stub.path)
+	return
+}
+
+// equalJson compares objects by their json encodings, with []byte taken to be json already.
+
+func equalJson(expected, actual any) bool {
+
+	normalize := func(obj any) (normal any, ok bool) {
+
+		data, isData := obj.([]byte)
+		if !isData {
+			var err error
+			data, err = json.Marshal(obj)
+			if err != nil {
+				return
+			}
+		}
+
+		if len(data) == 0 {
+			ok = true
+			return
+		}
+
+		ok = json.Unmarshal(data, &normal) == nil
+		return
+	}
+
+	expectedNormal, ok := normalize(expected)
+	if !ok {
+		return false
+	}
+	actualNormal, ok := normalize(actual)
+	if !ok {
+		return false
+	}
+
+	return reflect.DeepEqual(expectedNormal, actualNormal)
+}
+
+func read(reader io.Reader) (data []byte, err error) {
+
+	if reader == nil {
+		return
+	}
+
+	data, err = io.ReadAll(reader)
+	err = errors.Wrapf(err, "gianttest: failed to read body")
+	return
+}
@@ -0,0 +1,128 @@
+package gianttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clarktrimble/giant"
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGiantTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GiantTest Suite")
+}
+
+var _ = Describe("FakeClient", func() {
+
+	var (
+		fake *FakeClient
+		ctx  context.Context
+	)
+
+	BeforeEach(func() {
+		fake = &FakeClient{}
+		ctx = context.Background()
+	})
+
+	Describe("sending objects", func() {
+
+		BeforeEach(func() {
+			fake.On("GET", "/boxes/{id}").Return(box{Material: "cardboard"})
+			fake.On("POST", "/boxes").WithBody(map[string]any{"material": "wood"}).Return(`{"material": "wood", "size": 3}`)
+			fake.On("POST", "/boxes").ReturnError(errors.New("oops"))
+		})
+
+		It("returns canned objects per method and path", func() {
+			var rcv box
+			err := fake.SendObject(ctx, "GET", "/boxes/123?full=true", nil, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(box{Material: "cardboard"}))
+		})
+
+		It("matches on body and decodes json results", func() {
+			var rcv box
+			err := fake.SendObject(ctx, "POST", "/boxes", box{Material: "wood"}, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(box{Material: "wood", Size: 3}))
+
+			err = fake.SendObject(ctx, "POST", "/boxes", box{Material: "steel"}, &rcv)
+			Expect(err).To(MatchError("oops"))
+		})
+
+		It("records calls", func() {
+			_ = fake.SendObject(ctx, "GET", "/boxes/123", nil, nil)
+			_ = fake.SendObject(ctx, "DELETE", "/boxes/123", nil, nil)
+
+			Expect(fake.CallsTo("GET", "/boxes/{id}")).To(HaveLen(1))
+
+			unmatched := fake.Unmatched()
+			Expect(unmatched).To(HaveLen(1))
+			Expect(unmatched[0].Method).To(Equal("DELETE"))
+		})
+
+		It("errors when no stub matches", func() {
+			err := fake.SendObject(ctx, "PUT", "/boxes/123", nil, nil)
+			Expect(err).To(MatchError("gianttest: no stub matches PUT /boxes/123"))
+		})
+	})
+
+	Describe("limiting matches", func() {
+
+		BeforeEach(func() {
+			fake.On("GET", "/boxes?page=2").Return(`[]`)
+			fake.On("GET", "/boxes").Times(1).Return(`[{"material": "a"}]`)
+			fake.On("GET", "/boxes").Return(`[{"material": "b"}]`)
+		})
+
+		It("uses stubs in order", func() {
+			data, err := fake.SendJson(ctx, "GET", "/boxes", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`[{"material": "a"}]`))
+
+			data, err = fake.SendJson(ctx, "GET", "/boxes", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`[{"material": "b"}]`))
+
+			data, err = fake.SendJson(ctx, "GET", "/boxes?page=2", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`[]`))
+		})
+	})
+
+	Describe("round tripping", func() {
+
+		var (
+			getBox giant.Endpoint[boxQuery, box]
+		)
+
+		BeforeEach(func() {
+			getBox = giant.Endpoint[boxQuery, box]{Method: "GET", Path: "/boxes/{id}"}
+			fake.On("GET", "/boxes/123").Return(box{Material: "cardboard", Size: 2})
+			fake.On("GET", "/boxes/456").Status(404).Return(`{"error": "not found"}`)
+		})
+
+		It("serves endpoints", func() {
+			rcv, err := getBox.Call(ctx, fake.Giant(), boxQuery{Id: "123"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(box{Material: "cardboard", Size: 2}))
+
+			_, err = getBox.Call(ctx, fake.Giant(), boxQuery{Id: "456"})
+			Expect(err).To(MatchError(ContainSubstring(`unexpected status code 404 with body: {"error": "not found"}`)))
+
+			Expect(fake.Calls()).To(HaveLen(2))
+		})
+	})
+})
+
+type box struct {
+	Material string `json:"material"`
+	Size     int    `json:"size,omitempty"`
+}
+
+type boxQuery struct {
+	Id string `path:"id"`
+}
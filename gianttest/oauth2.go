@@ -0,0 +1,313 @@
+package gianttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clarktrimble/giant"
+	"github.com/clarktrimble/launch"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTokenPath is where AuthServer serves tokens.
+	DefaultTokenPath string = "/oauth/token"
+	// DefaultExpiresIn is the lifetime of tokens issued by AuthServer.
+	DefaultExpiresIn time.Duration = time.Hour
+)
+
+// AuthServer is a fake OAuth2 authorization server, issuing tokens for the client_credentials grant
+// on TokenPath and serving a protected resource on all other paths.
+type AuthServer struct {
+	// Server is the underlying test server.
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	TokenPath    string
+
+	expiresIn  time.Duration
+	tokens     map[string]time.Time
+	resource   http.Handler
+	failStatus int
+	failCode   string
+	counts     AuthCounts
+	mu         sync.Mutex
+}
+
+// AuthCounts are counts of requests seen by an AuthServer.
+type AuthCounts struct {
+	// TokenRequests is the number of requests to the token endpoint.
+	TokenRequests int
+	// Issued is the number of tokens issued.
+	Issued int
+	// ResourceRequests is the number of requests to the protected resource.
+	ResourceRequests int
+	// Rejected is the number of resource requests refused for want of a valid token.
+	Rejected int
+}
+
+// NewAuthServer starts an AuthServer accepting the client credentials, to be closed by the caller.
+func NewAuthServer(clientID, clientSecret string) (as *AuthServer) {
+
+	as = &AuthServer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenPath:    DefaultTokenPath,
+		expiresIn:    DefaultExpiresIn,
+		tokens:       map[string]time.Time{},
+		resource: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(writer, `{}`)
+		}),
+	}
+	as.Server = httptest.NewServer(http.HandlerFunc(as.serve))
+	return
+}
+
+// Close shuts down the server.
+func (as *AuthServer) Close() {
+
+	as.Server.Close()
+}
+
+// Uri returns the server's base uri.
+func (as *AuthServer) Uri() string {
+
+	return as.Server.URL
+}
+
+// Config returns a giant config pointed at the server, with OAuth2 configured for its client.
+func (as *AuthServer) Config() *giant.Config {
+
+	return &giant.Config{
+		BaseUri:      as.Server.URL,
+		Timeout:      fakeTimeout,
+		TimeoutShort: fakeTimeoutShort,
+		OAuth2: &giant.OAuth2Config{
+			TokenPath:    as.TokenPath,
+			ClientID:     as.ClientID,
+			ClientSecret: launch.Redact(as.ClientSecret),
+		},
+	}
+}
+
+// Resource sets the handler serving the protected resource, reached only with a valid token.
+// By default it responds with an empty json object.
+func (as *AuthServer) Resource(handler http.Handler) *AuthServer {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.resource = handler
+	return as
+}
+
+// ExpireIn sets the lifetime of tokens issued from here on, reported as expires_in.
+func (as *AuthServer) ExpireIn(expiresIn time.Duration) *AuthServer {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.expiresIn = expiresIn
+	return as
+}
+
+// Revoke invalidates issued tokens, all of them when none are given.
+func (as *AuthServer) Revoke(tokens ...string) {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if len(tokens) == 0 {
+		as.tokens = map[string]time.Time{}
+		return
+	}
+
+	for _, token := range tokens {
+		delete(as.tokens, token)
+	}
+}
+
+// Fail makes token requests fail with status and an OAuth2 error code, ex: invalid_client,
+// until called with a zero status.
+func (as *AuthServer) Fail(status int, code string) {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.failStatus = status
+	as.failCode = code
+}
+
+// Valid reports whether a token was issued and is neither expired nor revoked.
+func (as *AuthServer) Valid(token string) bool {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.valid(token)
+}
+
+// Counts returns counts of requests seen.
+func (as *AuthServer) Counts() AuthCounts {
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.counts
+}
+
+// Protect wraps a handler, responding 401 to requests without a valid bearer token.
+func (as *AuthServer) Protect(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+
+		as.mu.Lock()
+		as.counts.ResourceRequests++
+		valid := ok && as.valid(token)
+		if !valid {
+			as.counts.Rejected++
+		}
+		as.mu.Unlock()
+
+		if !valid {
+			writer.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			authError(writer, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// unexported
+
+func (as *AuthServer) serve(writer http.ResponseWriter, request *http.Request) {
+
+	if request.URL.Path == as.TokenPath {
+		as.token(writer, request)
+		return
+	}
+
+	as.mu.Lock()
+	resource := as.resource
+	as.mu.Unlock()
+
+	as.Protect(resource).ServeHTTP(writer, request)
+}
+
+// token issues a token for valid client_credentials grants, sent as json or form.
+
+func (as *AuthServer) token(writer http.ResponseWriter, request *http.Request) {
+
+	as.mu.Lock()
+	as.counts.TokenRequests++
+	failStatus, failCode := as.failStatus, as.failCode
+	as.mu.Unlock()
+
+	if failStatus != 0 {
+		authError(writer, failStatus, failCode)
+		return
+	}
+
+	if request.Method != http.MethodPost {
+		authError(writer, http.StatusMethodNotAllowed, "invalid_request")
+		return
+	}
+
+	params, err := tokenParams(request)
+	if err != nil {
+		authError(writer, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	if params.Get("grant_type") != "client_credentials" {
+		authError(writer, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	if params.Get("client_id") != as.ClientID || params.Get("client_secret") != as.ClientSecret {
+		authError(writer, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	as.mu.Lock()
+	as.counts.Issued++
+	token := fmt.Sprintf("token-%d", as.counts.Issued)
+	expiresIn := as.expiresIn
+	as.tokens[token] = time.Now().Add(expiresIn)
+	as.mu.Unlock()
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(expiresIn.Seconds()),
+	})
+}
+
+func (as *AuthServer) valid(token string) bool {
+
+	expires, ok := as.tokens[token]
+	return ok && time.Now().Before(expires)
+}
+
+// tokenParams reads token request params from a json or form body,
+// with client credentials optionally from basic auth.
+
+func tokenParams(request *http.Request) (params url.Values, err error) {
+
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/json":
+		var body map[string]string
+		err = json.NewDecoder(request.Body).Decode(&body)
+		if err != nil {
+			return
+		}
+
+		params = url.Values{}
+		for key, value := range body {
+			params.Set(key, value)
+		}
+	case "application/x-www-form-urlencoded":
+		err = request.ParseForm()
+		if err != nil {
+			return
+		}
+		params = request.PostForm
+	default:
+		err = errors.Errorf("unsupported content type %q", mediaType)
+		return
+	}
+
+	id, secret, ok := request.BasicAuth()
+	if ok {
+		params.Set("client_id", id)
+		params.Set("client_secret", secret)
+	}
+
+	return
+}
+
+func authError(writer http.ResponseWriter, status int, code string) {
+
+	writeJson(writer, status, map[string]string{"error": code})
+}
+
+func writeJson(writer http.ResponseWriter, status int, obj any) {
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(obj)
+}
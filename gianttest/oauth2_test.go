@@ -0,0 +1,148 @@
+package gianttest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/clarktrimble/giant"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthServer", func() {
+
+	var (
+		as  *AuthServer
+		gnt *giant.Giant
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		as = NewAuthServer("my-client", "my-secret")
+		ctx = context.Background()
+	})
+
+	JustBeforeEach(func() {
+		gnt = as.Config().NewWithTrippers(&nopLogger{})
+	})
+
+	AfterEach(func() {
+		as.Close()
+	})
+
+	Describe("serving the protected resource", func() {
+
+		It("fetches a token and sends it along", func() {
+			data, err := gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{}`))
+
+			Expect(as.Valid("token-1")).To(BeTrue())
+			Expect(as.Counts()).To(Equal(AuthCounts{TokenRequests: 1, Issued: 1, ResourceRequests: 1}))
+		})
+
+		It("refreshes once a token is revoked", func() {
+			_, err := gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			as.Revoke("token-1")
+
+			_, err = gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(as.Valid("token-1")).To(BeFalse())
+			Expect(as.Valid("token-2")).To(BeTrue())
+			Expect(as.Counts()).To(Equal(AuthCounts{TokenRequests: 2, Issued: 2, ResourceRequests: 3, Rejected: 1}))
+		})
+
+		It("refreshes once a token expires", func() {
+			as.ExpireIn(50 * time.Millisecond)
+
+			_, err := gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			as.ExpireIn(time.Hour)
+			time.Sleep(60 * time.Millisecond)
+
+			_, err = gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(as.Counts().Issued).To(Equal(2))
+		})
+
+		It("serves a custom resource", func() {
+			as.Resource(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				_, _ = writer.Write([]byte(`{"data": "thing1"}`))
+			}))
+
+			data, err := gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"data": "thing1"}`))
+		})
+
+		It("rejects requests without a valid token", func() {
+			response, err := http.Get(as.Uri() + "/data")
+			Expect(err).ToNot(HaveOccurred())
+			defer response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(401))
+			Expect(response.Header.Get("WWW-Authenticate")).To(Equal(`Bearer error="invalid_token"`))
+			Expect(as.Counts().Rejected).To(Equal(1))
+		})
+	})
+
+	Describe("issuing tokens", func() {
+
+		It("accepts form-encoded requests", func() {
+			form := url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {"my-client"},
+				"client_secret": {"my-secret"},
+			}
+
+			response, err := http.PostForm(as.Uri()+DefaultTokenPath, form)
+			Expect(err).ToNot(HaveOccurred())
+			defer response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(200))
+			Expect(as.Counts().Issued).To(Equal(1))
+		})
+
+		It("rejects bad credentials and grants", func() {
+			response, err := http.Post(as.Uri()+DefaultTokenPath, "application/json",
+				strings.NewReader(`{"grant_type": "client_credentials", "client_id": "my-client", "client_secret": "nope"}`))
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(401))
+
+			response, err = http.Post(as.Uri()+DefaultTokenPath, "application/json",
+				strings.NewReader(`{"grant_type": "password", "client_id": "my-client", "client_secret": "my-secret"}`))
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(400))
+
+			Expect(as.Counts()).To(Equal(AuthCounts{TokenRequests: 2}))
+		})
+
+		It("fails when told to", func() {
+			as.Fail(503, "temporarily_unavailable")
+
+			_, err := gnt.SendJson(ctx, "GET", "/data", nil)
+			Expect(err).To(MatchError(ContainSubstring(`token request returned 503: {"error":"temporarily_unavailable"}`)))
+			Expect(as.Counts().ResourceRequests).To(BeZero())
+		})
+	})
+})
+
+type nopLogger struct{}
+
+func (lgr *nopLogger) Info(ctx context.Context, msg string, kv ...any)             {}
+func (lgr *nopLogger) Debug(ctx context.Context, msg string, kv ...any)            {}
+func (lgr *nopLogger) Trace(ctx context.Context, msg string, kv ...any)            {}
+func (lgr *nopLogger) Error(ctx context.Context, msg string, err error, kv ...any) {}
+func (lgr *nopLogger) WithFields(ctx context.Context, kv ...any) context.Context {
+	return ctx
+}
@@ -0,0 +1,317 @@
+package gianttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/clarktrimble/giant"
+	"github.com/clarktrimble/giant/route"
+)
+
+const (
+	fakeTimeout      time.Duration = 10 * time.Second
+	fakeTimeoutShort time.Duration = 2 * time.Second
+)
+
+// Server is a scriptable fake upstream, serving responses per expectations on requests.
+// Requests matching no expectation get 501 Not Implemented and are recorded as unexpected.
+type Server struct {
+	// Server is the underlying test server.
+	Server       *httptest.Server
+	expectations []*Expectation
+	requests     []Request
+	unexpected   []Request
+	mu           sync.Mutex
+}
+
+// Request is a captured request.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Expectation matches requests and responds to them in sequence.
+type Expectation struct {
+	method    string
+	matcher   *route.Matcher
+	query     url.Values
+	header    http.Header
+	body      any
+	hasBody   bool
+	delay     time.Duration
+	responses []*response
+	served    int
+	requests  []Request
+	// mu is the server's, guarding requests as they are captured
+	mu *sync.Mutex
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+}
+
+// NewServer starts a Server, to be closed by the caller.
+func NewServer() (srv *Server) {
+
+	srv = &Server{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.serve))
+	return
+}
+
+// Close shuts down the server.
+func (srv *Server) Close() {
+
+	srv.Server.Close()
+}
+
+// Uri returns the server's base uri.
+func (srv *Server) Uri() string {
+
+	return srv.Server.URL
+}
+
+// Config returns a giant config with BaseUri set to the server's
+// and timeouts suited to tests, ready for New or NewWithTrippers.
+func (srv *Server) Config() *giant.Config {
+
+	return &giant.Config{
+		BaseUri:      srv.Server.URL,
+		Timeout:      fakeTimeout,
+		TimeoutShort: fakeTimeoutShort,
+	}
+}
+
+// Expect adds an expectation for a method and path, the path may have placeholders, ex: /users/{id}.
+// Expectations are tried in the order they were added.
+func (srv *Server) Expect(method, path string) (exp *Expectation) {
+
+	exp = &Expectation{
+		method:  method,
+		matcher: route.NewMatcher(path),
+		query:   url.Values{},
+		header:  http.Header{},
+		mu:      &srv.mu,
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.expectations = append(srv.expectations, exp)
+	return
+}
+
+// Requests returns all captured requests.
+func (srv *Server) Requests() []Request {
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return append([]Request{}, srv.requests...)
+}
+
+// Unexpected returns captured requests no expectation matched.
+func (srv *Server) Unexpected() []Request {
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return append([]Request{}, srv.unexpected...)
+}
+
+// WithQuery limits the expectation to requests having the query value.
+func (exp *Expectation) WithQuery(key, value string) *Expectation {
+
+	exp.query.Add(key, value)
+	return exp
+}
+
+// WithHeader limits the expectation to requests having the header value.
+func (exp *Expectation) WithHeader(key, value string) *Expectation {
+
+	exp.header.Add(key, value)
+	return exp
+}
+
+// WithJson limits the expectation to requests with a body equal to obj once both are encoded as json.
+func (exp *Expectation) WithJson(obj any) *Expectation {
+
+	exp.body = obj
+	exp.hasBody = true
+	return exp
+}
+
+// Respond adds a response to the sequence, the last of which is repeated.
+// A string or []byte body is sent as is, nil as empty, and anything else encoded as json.
+func (exp *Expectation) Respond(status int, body any) *Expectation {
+
+	rsp := &response{
+		status: status,
+		header: http.Header{},
+	}
+
+	switch body := body.(type) {
+	case nil:
+	case string:
+		rsp.body = []byte(body)
+	case []byte:
+		rsp.body = body
+	default:
+		var err error
+		rsp.body, err = json.Marshal(body)
+		if err != nil {
+			panic(fmt.Sprintf("gianttest: failed to encode response body: %s", err))
+		}
+		rsp.header.Set("Content-Type", "application/json")
+	}
+
+	exp.responses = append(exp.responses, rsp)
+	return exp
+}
+
+// Header sets a header on the last response added.
+func (exp *Expectation) Header(key, value string) *Expectation {
+
+	if len(exp.responses) == 0 {
+		exp.Respond(http.StatusOK, nil)
+	}
+
+	exp.responses[len(exp.responses)-1].header.Set(key, value)
+	return exp
+}
+
+// Delay injects latency before the last response added, or all when none are added yet.
+// Delays are cut short when the request is canceled.
+func (exp *Expectation) Delay(delay time.Duration) *Expectation {
+
+	if len(exp.responses) == 0 {
+		exp.delay = delay
+		return exp
+	}
+
+	exp.responses[len(exp.responses)-1].delay = delay
+	return exp
+}
+
+// Requests returns captured requests matching the expectation.
+func (exp *Expectation) Requests() []Request {
+
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+
+	return append([]Request{}, exp.requests...)
+}
+
+// unexported
+
+func (srv *Server) serve(writer http.ResponseWriter, request *http.Request) {
+
+	body, _ := io.ReadAll(request.Body)
+	captured := Request{
+		Method: request.Method,
+		Path:   request.URL.Path,
+		Query:  request.URL.Query(),
+		Header: request.Header.Clone(),
+		Body:   body,
+	}
+
+	rsp, delay := srv.respond(captured)
+	if rsp == nil {
+		http.Error(writer, fmt.Sprintf("gianttest: no expectation matches %s %s", request.Method, request.URL), http.StatusNotImplemented)
+		return
+	}
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-request.Context().Done():
+			return
+		}
+	}
+
+	for key, values := range rsp.header {
+		writer.Header()[key] = values
+	}
+	writer.WriteHeader(rsp.status)
+	_, _ = writer.Write(rsp.body)
+}
+
+// respond records the request and finds the next response of the first matching expectation.
+
+func (srv *Server) respond(captured Request) (rsp *response, delay time.Duration) {
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.requests = append(srv.requests, captured)
+
+	for _, exp := range srv.expectations {
+		if !exp.match(captured) {
+			continue
+		}
+
+		exp.requests = append(exp.requests, captured)
+
+		rsp = &response{status: http.StatusOK}
+		if len(exp.responses) > 0 {
+			rsp = exp.responses[min(exp.served, len(exp.responses)-1)]
+		}
+		exp.served++
+
+		delay = exp.delay
+		if rsp.delay > 0 {
+			delay = rsp.delay
+		}
+		return
+	}
+
+	srv.unexpected = append(srv.unexpected, captured)
+	return
+}
+
+func (exp *Expectation) match(captured Request) bool {
+
+	if captured.Method != exp.method || exp.matcher.Match(captured.Path) == "" {
+		return false
+	}
+
+	for key, values := range exp.query {
+		for _, value := range values {
+			if !contains(captured.Query[key], value) {
+				return false
+			}
+		}
+	}
+
+	for key, values := range exp.header {
+		for _, value := range values {
+			if !contains(captured.Header.Values(key), value) {
+				return false
+			}
+		}
+	}
+
+	return !exp.hasBody || equalJson(exp.body, captured.Body)
+}
+
+func contains(values []string, value string) bool {
+
+	for _, each := range values {
+		if each == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package gianttest
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/clarktrimble/giant"
+	"github.com/clarktrimble/giant/statusrt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+
+	var (
+		srv *Server
+		gnt *giant.Giant
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		srv = NewServer()
+		gnt = srv.Config().New()
+		gnt.Use(&statusrt.StatusRt{})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	Describe("matching expectations", func() {
+
+		BeforeEach(func() {
+			srv.Expect("GET", "/boxes/{id}").
+				WithQuery("full", "true").
+				WithHeader("Accept", "application/json").
+				Respond(200, box{Material: "cardboard"})
+			srv.Expect("POST", "/boxes").
+				WithJson(map[string]any{"material": "wood"}).
+				Respond(201, `{"material": "wood", "size": 3}`).
+				Header("Content-Type", "application/json").
+				Header("Location", "/boxes/456")
+		})
+
+		It("responds per method, path, query and headers", func() {
+			var rcv box
+			err := gnt.SendObject(ctx, "GET", "/boxes/123?full=true", nil, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(box{Material: "cardboard"}))
+
+			Expect(srv.Unexpected()).To(BeEmpty())
+		})
+
+		It("responds per json body", func() {
+			var rcv box
+			err := gnt.SendObject(ctx, "POST", "/boxes", box{Material: "wood"}, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(box{Material: "wood", Size: 3}))
+		})
+
+		It("sets response headers", func() {
+			response, err := gnt.Client.Post(srv.Uri()+"/boxes", "application/json", strings.NewReader(`{"material":"wood"}`))
+			Expect(err).ToNot(HaveOccurred())
+			defer response.Body.Close()
+
+			Expect(response.StatusCode).To(Equal(201))
+			Expect(response.Header.Get("Location")).To(Equal("/boxes/456"))
+		})
+
+		It("captures requests and rejects the unexpected", func() {
+			_, err := gnt.SendJson(ctx, "GET", "/boxes/123", nil)
+			Expect(err).To(MatchError(ContainSubstring("unexpected status code 501")))
+
+			Expect(srv.Requests()).To(HaveLen(1))
+
+			unexpected := srv.Unexpected()
+			Expect(unexpected).To(HaveLen(1))
+			Expect(unexpected[0].Path).To(Equal("/boxes/123"))
+			Expect(unexpected[0].Header.Get("Accept")).To(Equal("application/json"))
+		})
+	})
+
+	Describe("sequencing responses", func() {
+
+		var (
+			exp *Expectation
+		)
+
+		BeforeEach(func() {
+			exp = srv.Expect("GET", "/boxes").
+				Respond(503, "try again").
+				Respond(200, `[]`)
+		})
+
+		It("responds in order, repeating the last", func() {
+			_, err := gnt.SendJson(ctx, "GET", "/boxes", nil)
+			Expect(err).To(MatchError(ContainSubstring("unexpected status code 503 with body: try again")))
+
+			for range 2 {
+				data, err := gnt.SendJson(ctx, "GET", "/boxes", nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal(`[]`))
+			}
+
+			Expect(exp.Requests()).To(HaveLen(3))
+		})
+
+		It("captures requests while they are in flight", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				for range 3 {
+					_, _ = gnt.SendJson(ctx, "GET", "/boxes", nil)
+				}
+			}()
+
+			Eventually(exp.Requests).Should(HaveLen(3))
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Describe("injecting latency", func() {
+
+		BeforeEach(func() {
+			srv.Expect("GET", "/slow").Respond(200, `{}`).Delay(200 * time.Millisecond)
+		})
+
+		It("delays the response", func() {
+			start := time.Now()
+			_, err := gnt.SendJson(ctx, "GET", "/slow", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		})
+
+		It("gives up when the request is canceled", func() {
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+
+			_, err := gnt.SendJson(ctx, "GET", "/slow", nil)
+			Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+		})
+	})
+
+	Describe("configuring a client", func() {
+
+		It("sets base uri and timeouts", func() {
+			cfg := srv.Config()
+			Expect(cfg.BaseUri).To(HavePrefix("http://127.0.0.1:"))
+			Expect(cfg.Timeout).ToNot(BeZero())
+			Expect(gnt.BaseUri).To(Equal(srv.Uri()))
+		})
+	})
+})
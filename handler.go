@@ -0,0 +1,211 @@
+package giant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// HandlerTransport is an http.RoundTripper dispatching requests to a handler in-process, without sockets.
+// Trippers wrapping it run as usual, and response bodies stream as the handler writes them.
+type HandlerTransport struct {
+	Handler http.Handler
+}
+
+// NewHandlerTransport creates a HandlerTransport.
+func NewHandlerTransport(handler http.Handler) *HandlerTransport {
+
+	return &HandlerTransport{Handler: handler}
+}
+
+// RoundTrip serves the request with the handler, returning once headers are written.
+// The handler's context is canceled when the request's is, or when the response body is closed.
+func (ht *HandlerTransport) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	ctx, cancel := context.WithCancel(request.Context())
+	serverRequest := inbound(ctx, request)
+
+	reader, pipeWriter := io.Pipe()
+	writer := &handlerWriter{
+		header: http.Header{},
+		pipe:   pipeWriter,
+		ready:  make(chan struct{}),
+	}
+
+	var served error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer closeBody(request)
+
+		served = serve(ht.Handler, writer, serverRequest)
+		if served == nil {
+			writer.writeHeader(http.StatusOK)
+		}
+		pipeWriter.CloseWithError(served)
+	}()
+
+	select {
+	case <-writer.ready:
+	case <-done:
+		if writer.status == 0 {
+			cancel()
+			err = served
+			return
+		}
+	case <-ctx.Done():
+		cancel()
+		reader.CloseWithError(ctx.Err())
+		err = errors.Wrapf(ctx.Err(), "request to handler canceled")
+		return
+	}
+
+	// close the body when canceled, erroring reads after
+
+	go func() {
+		<-ctx.Done()
+		pipeWriter.CloseWithError(ctx.Err())
+	}()
+
+	response = &http.Response{
+		Status:        fmt.Sprintf("%d %s", writer.status, http.StatusText(writer.status)),
+		StatusCode:    writer.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        writer.sent,
+		Body:          &handlerBody{PipeReader: reader, cancel: cancel},
+		ContentLength: contentLength(writer.sent),
+		Request:       request,
+	}
+	return
+}
+
+// unexported
+
+// handlerWriter is an http.ResponseWriter piping the body back to RoundTrip.
+
+type handlerWriter struct {
+	header http.Header
+	sent   http.Header
+	status int
+	pipe   *io.PipeWriter
+	ready  chan struct{}
+}
+
+func (hw *handlerWriter) Header() http.Header {
+
+	return hw.header
+}
+
+func (hw *handlerWriter) WriteHeader(status int) {
+
+	hw.writeHeader(status)
+}
+
+func (hw *handlerWriter) Write(data []byte) (count int, err error) {
+
+	if hw.status == 0 {
+		if hw.header.Get("Content-Type") == "" {
+			hw.header.Set("Content-Type", http.DetectContentType(data))
+		}
+		hw.writeHeader(http.StatusOK)
+	}
+
+	count, err = hw.pipe.Write(data)
+	return
+}
+
+// Flush sends headers if not sent already, as body writes are unbuffered.
+func (hw *handlerWriter) Flush() {
+
+	hw.writeHeader(http.StatusOK)
+}
+
+// writeHeader snapshots headers and signals RoundTrip, once.
+
+func (hw *handlerWriter) writeHeader(status int) {
+
+	if hw.status != 0 {
+		return
+	}
+
+	hw.status = status
+	hw.sent = hw.header.Clone()
+	close(hw.ready)
+}
+
+// handlerBody cancels the handler's context when closed.
+
+type handlerBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (hb *handlerBody) Close() error {
+
+	hb.cancel()
+	return hb.PipeReader.Close()
+}
+
+// inbound returns a request as a server would see it.
+
+func inbound(ctx context.Context, request *http.Request) (serverRequest *http.Request) {
+
+	serverRequest = request.Clone(ctx)
+	serverRequest.URL = &url.URL{
+		Path:     request.URL.Path,
+		RawPath:  request.URL.RawPath,
+		RawQuery: request.URL.RawQuery,
+	}
+	serverRequest.RequestURI = request.URL.RequestURI()
+	serverRequest.Proto = "HTTP/1.1"
+	serverRequest.ProtoMajor = 1
+	serverRequest.ProtoMinor = 1
+	serverRequest.RemoteAddr = "127.0.0.1:0"
+
+	if serverRequest.Host == "" {
+		serverRequest.Host = request.URL.Host
+	}
+	if serverRequest.Body == nil {
+		serverRequest.Body = http.NoBody
+	}
+
+	return
+}
+
+// serve runs the handler, returning a panic as error.
+
+func serve(handler http.Handler, writer http.ResponseWriter, request *http.Request) (err error) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = errors.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+
+	handler.ServeHTTP(writer, request)
+	return
+}
+
+func closeBody(request *http.Request) {
+
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+func contentLength(header http.Header) int64 {
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return length
+}
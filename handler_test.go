@@ -0,0 +1,166 @@
+package giant
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandlerTransport", func() {
+
+	var (
+		gnt      *Giant
+		handler  http.HandlerFunc
+		received *http.Request
+		body     string
+		logged   []string
+		skipBody bool
+		ctx      context.Context
+	)
+
+	BeforeEach(func() {
+		logged = nil
+		skipBody = false
+		ctx = context.Background()
+
+		handler = func(writer http.ResponseWriter, request *http.Request) {
+			data, _ := io.ReadAll(request.Body)
+			received = request
+			body = string(data)
+
+			writer.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(writer, `{"data": "thing2"}`)
+		}
+	})
+
+	JustBeforeEach(func() {
+		cfg := &Config{
+			BaseUri:  "http://boxworld.internal",
+			Handler:  handler,
+			SkipBody: skipBody,
+		}
+
+		gnt = cfg.NewWithTrippers(&LoggerMock{
+			InfoFunc:       func(ctx context.Context, msg string, kv ...any) {},
+			DebugFunc:      func(ctx context.Context, msg string, kv ...any) {},
+			TraceFunc:      func(ctx context.Context, msg string, kv ...any) { logged = append(logged, msg) },
+			WithFieldsFunc: func(ctx context.Context, kv ...any) context.Context { return ctx },
+		})
+	})
+
+	Describe("sending a request", func() {
+
+		It("dispatches to the handler with trippers running", func() {
+			var rcv foo
+			err := gnt.SendObject(ctx, "POST", "/boxes?size=3", foo{Data: "thing1"}, &rcv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcv).To(Equal(foo{Data: "thing2"}))
+
+			Expect(received.RequestURI).To(Equal("/boxes?size=3"))
+			Expect(received.Host).To(Equal("boxworld.internal"))
+			Expect(received.URL.Query().Get("size")).To(Equal("3"))
+			Expect(body).To(Equal(`{"data":"thing1"}`))
+			Expect(received.Header.Get("X-Request-Id")).To(HaveLen(7))
+
+			Expect(logged).To(Equal([]string{"sending request", "received response"}))
+		})
+
+		When("the handler errors by status", func() {
+			BeforeEach(func() {
+				handler = func(writer http.ResponseWriter, request *http.Request) {
+					http.Error(writer, "nope", http.StatusTeapot)
+				}
+			})
+
+			It("returns a status error", func() {
+				_, err := gnt.SendJson(ctx, "GET", "/boxes", nil)
+				Expect(err).To(MatchError(ContainSubstring("unexpected status code 418")))
+			})
+		})
+
+		When("the handler panics", func() {
+			BeforeEach(func() {
+				handler = func(writer http.ResponseWriter, request *http.Request) {
+					panic("oops")
+				}
+			})
+
+			It("returns an error", func() {
+				_, err := gnt.SendJson(ctx, "GET", "/boxes", nil)
+				Expect(err).To(MatchError(ContainSubstring("handler panicked: oops")))
+			})
+		})
+	})
+
+	Describe("streaming a response", func() {
+
+		var (
+			proceed chan struct{}
+		)
+
+		BeforeEach(func() {
+			// LogRt reads whole bodies unless skipped
+			skipBody = true
+			proceed = make(chan struct{})
+
+			handler = func(writer http.ResponseWriter, request *http.Request) {
+				fmt.Fprintln(writer, "first")
+				writer.(http.Flusher).Flush()
+
+				<-proceed
+				fmt.Fprintln(writer, "second")
+			}
+		})
+
+		It("returns chunks as they are written", func() {
+			request, err := http.NewRequestWithContext(ctx, "GET", "http://boxworld.internal/stream", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			response, err := gnt.Client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			defer response.Body.Close()
+
+			reader := bufio.NewReader(response.Body)
+			line, err := reader.ReadString('\n')
+			Expect(err).ToNot(HaveOccurred())
+			Expect(line).To(Equal("first\n"))
+
+			close(proceed)
+
+			rest, err := io.ReadAll(reader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(rest)).To(Equal("second\n"))
+		})
+	})
+
+	Describe("canceling a request", func() {
+
+		var (
+			canceled chan error
+		)
+
+		BeforeEach(func() {
+			canceled = make(chan error, 1)
+
+			handler = func(writer http.ResponseWriter, request *http.Request) {
+				<-request.Context().Done()
+				canceled <- request.Context().Err()
+			}
+		})
+
+		It("cancels the handler's context as well", func() {
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+
+			_, err := gnt.SendJson(ctx, "GET", "/slow", nil)
+			Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+			Eventually(canceled).Should(Receive(MatchError(context.DeadlineExceeded)))
+		})
+	})
+})
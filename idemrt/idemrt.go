@@ -0,0 +1,79 @@
+// Package idemrt implements the Tripper interface, adding an idempotency key header to unsafe requests.
+package idemrt
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/clarktrimble/hondo"
+)
+
+const (
+	keyLen        int    = 20
+	defaultHeader string = "Idempotency-Key"
+)
+
+// IdemRt implements the Tripper interface.
+type IdemRt struct {
+	// Header is the name of the key header.
+	Header string
+	// Methods are those for which a key is sent.
+	Methods map[string]bool
+	next    http.RoundTripper
+}
+
+// New creates an IdemRt for POST and PATCH, with header defaulting to Idempotency-Key when blank.
+func New(header string) *IdemRt {
+
+	if header == "" {
+		header = defaultHeader
+	}
+
+	return &IdemRt{
+		Header: header,
+		Methods: map[string]bool{
+			http.MethodPost:  true,
+			http.MethodPatch: true,
+		},
+	}
+}
+
+// WithKey returns a context carrying a key, to be sent with each request made with it.
+// Use to hold a key stable across retries of a logical call made above the tripper.
+func WithKey(ctx context.Context, key string) context.Context {
+
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// NewKey returns a context carrying a freshly generated key.
+func NewKey(ctx context.Context) context.Context {
+
+	return WithKey(ctx, hondo.Rand(keyLen))
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *IdemRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip adds a key header to requests with a listed method.
+// The key is taken from the request if already set, then from context, and generated otherwise.
+func (rt *IdemRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	if rt.Methods[request.Method] && request.Header.Get(rt.Header) == "" {
+
+		key, _ := request.Context().Value(keyKey{}).(string)
+		if key == "" {
+			key = hondo.Rand(keyLen)
+		}
+
+		request.Header.Set(rt.Header, key)
+	}
+
+	response, err = rt.next.RoundTrip(request)
+	return
+}
+
+// unexported
+
+type keyKey struct{}
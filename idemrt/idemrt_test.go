@@ -0,0 +1,118 @@
+package idemrt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestIdemRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IdemRt Suite")
+}
+
+var _ = Describe("IdemRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt      *IdemRt
+			trt     *testRt
+			request *http.Request
+			ctx     context.Context
+			method  string
+			err     error
+		)
+
+		BeforeEach(func() {
+			rt = New("")
+			trt = &testRt{Status: 201}
+			rt.Wrap(trt)
+
+			ctx = context.Background()
+			method = "POST"
+		})
+
+		JustBeforeEach(func() {
+			request, err = http.NewRequestWithContext(ctx, method, "https://boxworld.org/cardboard", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = rt.RoundTrip(request)
+		})
+
+		Describe("adding key header", func() {
+
+			When("method is POST", func() {
+				It("generates a key", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Keys).To(HaveLen(1))
+					Expect(trt.Keys[0]).To(HaveLen(20))
+				})
+
+				It("keeps the key when retried", func() {
+					_, err = rt.RoundTrip(request)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Keys).To(HaveLen(2))
+					Expect(trt.Keys[1]).To(Equal(trt.Keys[0]))
+				})
+			})
+
+			When("key is in context", func() {
+				BeforeEach(func() {
+					ctx = WithKey(ctx, "from-context")
+				})
+
+				It("uses it", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Keys).To(Equal([]string{"from-context"}))
+				})
+			})
+
+			When("method is GET", func() {
+				BeforeEach(func() {
+					method = "GET"
+				})
+
+				It("does not add a key", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Keys).To(Equal([]string{""}))
+				})
+			})
+
+			When("header is configured", func() {
+				BeforeEach(func() {
+					rt.Header = "X-Request-Key"
+				})
+
+				It("uses the header", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(request.Header.Get("X-Request-Key")).To(HaveLen(20))
+					Expect(request.Header.Get("Idempotency-Key")).To(BeEmpty())
+				})
+			})
+		})
+	})
+})
+
+type testRt struct {
+	Status int
+	Keys   []string
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	rt.Keys = append(rt.Keys, request.Header.Get("Idempotency-Key"))
+
+	response = &http.Response{
+		StatusCode: rt.Status,
+		Body:       io.NopCloser(strings.NewReader(`{"ima": "pc"}`)),
+		Request:    request,
+	}
+
+	return
+}
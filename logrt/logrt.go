@@ -9,13 +9,16 @@ import (
 	"time"
 
 	"github.com/clarktrimble/giant/logger"
-	"github.com/clarktrimble/hondo"
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/route"
 	"github.com/pkg/errors"
 )
 
-const (
-	idLen int = 7
-)
+// Describer is implemented by request bodies that are logged by description
+// rather than read, such as streamed multipart uploads.
+type Describer interface {
+	Describe() any
+}
 
 // LogRt implements the Tripper interface logging requests and responses.
 type LogRt struct {
@@ -49,12 +52,21 @@ func (rt *LogRt) Wrap(next http.RoundTripper) {
 }
 
 // RoundTrip logs the request and response.
+// The request id is taken from context when found there and generated and added otherwise,
+// for trippers it wraps, such as reqid.IdRt, to send the same id.
+// The route template is logged as well, when found in context.
 func (rt *LogRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
 
 	start := time.Now()
 
-	ctx := request.Context()
-	ctx = rt.Logger.WithFields(ctx, "request_id", hondo.Rand(idLen))
+	ctx, id := reqid.Ensure(request.Context())
+
+	fields := []any{"request_id", id}
+	if rte := route.From(ctx); rte != "" {
+		fields = append(fields, "route", rte)
+	}
+
+	ctx = rt.Logger.WithFields(ctx, fields...)
 	request = request.WithContext(ctx)
 
 	rt.Logger.Trace(ctx, "sending request", rt.requestFields(request)...)
@@ -83,7 +95,13 @@ func (rt *LogRt) requestFields(request *http.Request) (fields []any) {
 		"query", request.URL.Query(),
 	}
 
-	if !rt.SkipBody {
+	if describer, ok := request.Body.(Describer); ok && !rt.SkipBody {
+
+		// log description in place of streamed content
+
+		fields = append(fields, "body")
+		fields = append(fields, describer.Describe())
+	} else if !rt.SkipBody {
 
 		// read body and put it back
 
@@ -10,6 +10,9 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/route"
 )
 
 //go:generate moq -pkg logrt -out mock_test.go ../logger Logger
@@ -68,10 +71,10 @@ var _ = Describe("LogRt", func() {
 
 					wfc := lgr.WithFieldsCalls()
 					Expect(wfc).To(HaveLen(1))
-					Expect(wfc[0].Ctx).To(Equal(ctx))
 					Expect(wfc[0].Kv).To(HaveLen(2))
 					Expect(wfc[0].Kv[0]).To(Equal("request_id"))
 					Expect(wfc[0].Kv[1]).To(HaveLen(7))
+					Expect(reqid.From(wfc[0].Ctx)).To(Equal(wfc[0].Kv[1]))
 
 					ic := lgr.TraceCalls()
 					Expect(ic).To(HaveLen(2))
@@ -134,10 +137,10 @@ var _ = Describe("LogRt", func() {
 
 					wfc := lgr.WithFieldsCalls()
 					Expect(wfc).To(HaveLen(1))
-					Expect(wfc[0].Ctx).To(Equal(ctx))
 					Expect(wfc[0].Kv).To(HaveLen(2))
 					Expect(wfc[0].Kv[0]).To(Equal("request_id"))
 					Expect(wfc[0].Kv[1]).To(HaveLen(7))
+					Expect(reqid.From(wfc[0].Ctx)).To(Equal(wfc[0].Kv[1]))
 
 					ic := lgr.TraceCalls()
 					Expect(ic).To(HaveLen(2))
@@ -182,11 +185,80 @@ var _ = Describe("LogRt", func() {
 					Expect(string(body)).To(Equal(`{"ima": "pc"}`))
 				})
 			})
+
+			When("request id is in context", func() {
+				BeforeEach(func() {
+					request = request.WithContext(reqid.With(ctx, "abc1234"))
+				})
+
+				It("logs the id without sending it", func() {
+
+					Expect(err).ToNot(HaveOccurred())
+
+					wfc := lgr.WithFieldsCalls()
+					Expect(wfc).To(HaveLen(1))
+					Expect(wfc[0].Kv).To(Equal([]any{"request_id", "abc1234"}))
+
+					Expect(request.Header.Get("X-Request-Id")).To(BeEmpty())
+				})
+			})
+
+			When("route is in context", func() {
+				BeforeEach(func() {
+					request = request.WithContext(route.With(reqid.With(ctx, "abc1234"), "/{material}"))
+				})
+
+				It("logs the route", func() {
+
+					Expect(err).ToNot(HaveOccurred())
+
+					wfc := lgr.WithFieldsCalls()
+					Expect(wfc).To(HaveLen(1))
+					Expect(wfc[0].Kv).To(Equal([]any{"request_id", "abc1234", "route", "/{material}"}))
+				})
+			})
+
+			When("body describes itself", func() {
+				BeforeEach(func() {
+					request.Body = &describedBody{}
+				})
+
+				It("logs the description without reading", func() {
+
+					Expect(err).ToNot(HaveOccurred())
+
+					ic := lgr.TraceCalls()
+					Expect(ic).To(HaveLen(2))
+					Expect(ic[0].Msg).To(Equal("sending request"))
+					Expect(ic[0].Kv).To(HaveLen(14))
+					Expect(ic[0].Kv[12]).To(Equal("body"))
+					Expect(ic[0].Kv[13]).To(Equal([]string{"part-one"}))
+
+					Expect(request.Body.(*describedBody).Reads).To(Equal(0))
+				})
+			})
 		})
 
 	})
 })
 
+type describedBody struct {
+	Reads int
+}
+
+func (body *describedBody) Read(data []byte) (int, error) {
+	body.Reads++
+	return 0, io.EOF
+}
+
+func (body *describedBody) Close() error {
+	return nil
+}
+
+func (body *describedBody) Describe() any {
+	return []string{"part-one"}
+}
+
 type testRt struct {
 	Status int
 }
@@ -0,0 +1,135 @@
+package metricsrt
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	countName    string = "giant_client_requests_total"
+	durationName string = "giant_client_request_duration_seconds"
+	textType     string = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds, as favored by Prometheus.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Memory is a Recorder keeping counts and latency histograms in memory,
+// and an http.Handler rendering them in Prometheus text format.
+type Memory struct {
+	// Buckets are sorted histogram upper bounds in seconds.
+	Buckets []float64
+	series  map[LabelSet]*series
+	mu      sync.Mutex
+}
+
+// NewMemory creates a Memory recorder, with default buckets when none are given.
+func NewMemory(buckets []float64) *Memory {
+
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Memory{
+		Buckets: buckets,
+		series:  map[LabelSet]*series{},
+	}
+}
+
+// Record counts a request and observes its latency.
+func (mem *Memory) Record(labels LabelSet, elapsed time.Duration) {
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	srs, ok := mem.series[labels]
+	if !ok {
+		srs = &series{buckets: make([]uint64, len(mem.Buckets))}
+		mem.series[labels] = srs
+	}
+
+	seconds := elapsed.Seconds()
+	srs.count++
+	srs.sum += seconds
+
+	for i, bound := range mem.Buckets {
+		if seconds <= bound {
+			srs.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP writes metrics in Prometheus text exposition format.
+func (mem *Memory) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+
+	writer.Header().Set("Content-Type", textType)
+	_, _ = writer.Write(mem.Text())
+}
+
+// Text renders metrics in Prometheus text exposition format, ordered by labels.
+func (mem *Memory) Text() []byte {
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	keys := make([]LabelSet, 0, len(mem.series))
+	for labels := range mem.series {
+		keys = append(keys, labels)
+	}
+	slices.SortFunc(keys, func(a, b LabelSet) int {
+		return strings.Compare(a.text(), b.text())
+	})
+
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "# HELP %s Upstream requests by host, method, route and status class.\n", countName)
+	fmt.Fprintf(buf, "# TYPE %s counter\n", countName)
+	for _, labels := range keys {
+		fmt.Fprintf(buf, "%s{%s} %d\n", countName, labels.text(), mem.series[labels].count)
+	}
+
+	fmt.Fprintf(buf, "# HELP %s Upstream request latency by host, method, route and status class.\n", durationName)
+	fmt.Fprintf(buf, "# TYPE %s histogram\n", durationName)
+	for _, labels := range keys {
+
+		srs := mem.series[labels]
+		for i, bound := range mem.Buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(buf, "%s_bucket{%s,le=%q} %d\n", durationName, labels.text(), le, srs.buckets[i])
+		}
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", durationName, labels.text(), srs.count)
+		fmt.Fprintf(buf, "%s_sum{%s} %s\n", durationName, labels.text(), strconv.FormatFloat(srs.sum, 'g', -1, 64))
+		fmt.Fprintf(buf, "%s_count{%s} %d\n", durationName, labels.text(), srs.count)
+	}
+
+	return buf.Bytes()
+}
+
+// unexported
+
+type series struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (labels LabelSet) text() string {
+
+	return fmt.Sprintf(`host="%s",method="%s",route="%s",status="%s"`,
+		labelEscaper.Replace(labels.Host),
+		labelEscaper.Replace(labels.Method),
+		labelEscaper.Replace(labels.Route),
+		labelEscaper.Replace(labels.Status),
+	)
+}
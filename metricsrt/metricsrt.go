@@ -0,0 +1,88 @@
+// Package metricsrt implements the Tripper interface
+// recording request counts and latencies with a pluggable recorder.
+package metricsrt
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/clarktrimble/giant/route"
+)
+
+const (
+	errorClass string = "error"
+	// OtherRoute labels requests with no route template, rather than their unbounded paths.
+	OtherRoute string = "other"
+)
+
+// LabelSet is the dimensions requests are recorded by.
+type LabelSet struct {
+	Host   string
+	Method string
+	// Route is the route template from context, or OtherRoute when not found.
+	Route string
+	// Status is the status class, ex: 2xx, or "error" when no response was received.
+	Status string
+}
+
+// Recorder specifies a backend that requests are recorded with.
+// Implementations are expected to count requests and observe latency per labels.
+type Recorder interface {
+	Record(labels LabelSet, elapsed time.Duration)
+}
+
+// MetricsRt implements the Tripper interface.
+type MetricsRt struct {
+	Recorder Recorder
+	next     http.RoundTripper
+}
+
+// New creates a MetricsRt.
+func New(recorder Recorder) *MetricsRt {
+
+	return &MetricsRt{Recorder: recorder}
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *MetricsRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip records the request once a response or error is received.
+func (rt *MetricsRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	start := time.Now()
+
+	response, err = rt.next.RoundTrip(request)
+
+	labels := LabelSet{
+		Host:   request.URL.Host,
+		Method: request.Method,
+		Route:  routeOf(request),
+		Status: errorClass,
+	}
+	if response != nil {
+		labels.Status = statusClass(response.StatusCode)
+	}
+
+	rt.Recorder.Record(labels, time.Since(start))
+	return
+}
+
+// unexported
+
+func routeOf(request *http.Request) string {
+
+	tmpl := route.From(request.Context())
+	if tmpl == "" {
+		return OtherRoute
+	}
+
+	return tmpl
+}
+
+func statusClass(code int) string {
+
+	return fmt.Sprintf("%dxx", code/100)
+}
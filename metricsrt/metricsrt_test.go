@@ -0,0 +1,171 @@
+package metricsrt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clarktrimble/giant/route"
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetricsRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MetricsRt Suite")
+}
+
+var _ = Describe("MetricsRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt  *MetricsRt
+			trt *testRt
+			rcd *testRecorder
+			ctx context.Context
+			err error
+		)
+
+		BeforeEach(func() {
+			rcd = &testRecorder{}
+			rt = New(rcd)
+			trt = &testRt{Status: 404}
+			rt.Wrap(trt)
+			ctx = context.Background()
+		})
+
+		JustBeforeEach(func() {
+			var request *http.Request
+			request, err = http.NewRequestWithContext(ctx, "GET", "https://boxworld.org/cardboard", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = rt.RoundTrip(request)
+		})
+
+		When("a response is received", func() {
+			It("records with status class", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rcd.Labels).To(Equal([]LabelSet{{
+					Host:   "boxworld.org",
+					Method: "GET",
+					Route:  "other",
+					Status: "4xx",
+				}}))
+			})
+		})
+
+		When("route is in context", func() {
+			BeforeEach(func() {
+				ctx = route.With(ctx, "/{material}")
+			})
+
+			It("records the route template", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rcd.Labels).To(HaveLen(1))
+				Expect(rcd.Labels[0].Route).To(Equal("/{material}"))
+			})
+		})
+
+		When("route matcher misses", func() {
+			BeforeEach(func() {
+				ctx = route.With(ctx, route.NewMatcher("/{material}/{id}").Match("/cardboard"))
+			})
+
+			It("records the other route rather than the path", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rcd.Labels).To(HaveLen(1))
+				Expect(rcd.Labels[0].Route).To(Equal("other"))
+			})
+		})
+
+		When("the round trip fails", func() {
+			BeforeEach(func() {
+				trt.Err = errors.New("oops")
+			})
+
+			It("records an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(rcd.Labels).To(HaveLen(1))
+				Expect(rcd.Labels[0].Status).To(Equal("error"))
+			})
+		})
+	})
+
+	Describe("memory recorder", func() {
+
+		var (
+			mem *Memory
+		)
+
+		BeforeEach(func() {
+			mem = NewMemory([]float64{1, 0.1})
+
+			labels := LabelSet{Host: "boxworld.org", Method: "GET", Route: `/card"board`, Status: "2xx"}
+			mem.Record(labels, 50*time.Millisecond)
+			mem.Record(labels, 500*time.Millisecond)
+			mem.Record(LabelSet{Host: "boxworld.org", Method: "PUT", Route: "/cardboard", Status: "error"}, 2*time.Second)
+		})
+
+		It("renders prometheus text", func() {
+			recorder := httptest.NewRecorder()
+			mem.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+			Expect(recorder.Body.String()).To(Equal(strings.Join([]string{
+				`# HELP giant_client_requests_total Upstream requests by host, method, route and status class.`,
+				`# TYPE giant_client_requests_total counter`,
+				`giant_client_requests_total{host="boxworld.org",method="GET",route="/card\"board",status="2xx"} 2`,
+				`giant_client_requests_total{host="boxworld.org",method="PUT",route="/cardboard",status="error"} 1`,
+				`# HELP giant_client_request_duration_seconds Upstream request latency by host, method, route and status class.`,
+				`# TYPE giant_client_request_duration_seconds histogram`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="GET",route="/card\"board",status="2xx",le="0.1"} 1`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="GET",route="/card\"board",status="2xx",le="1"} 2`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="GET",route="/card\"board",status="2xx",le="+Inf"} 2`,
+				`giant_client_request_duration_seconds_sum{host="boxworld.org",method="GET",route="/card\"board",status="2xx"} 0.55`,
+				`giant_client_request_duration_seconds_count{host="boxworld.org",method="GET",route="/card\"board",status="2xx"} 2`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="PUT",route="/cardboard",status="error",le="0.1"} 0`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="PUT",route="/cardboard",status="error",le="1"} 0`,
+				`giant_client_request_duration_seconds_bucket{host="boxworld.org",method="PUT",route="/cardboard",status="error",le="+Inf"} 1`,
+				`giant_client_request_duration_seconds_sum{host="boxworld.org",method="PUT",route="/cardboard",status="error"} 2`,
+				`giant_client_request_duration_seconds_count{host="boxworld.org",method="PUT",route="/cardboard",status="error"} 1`,
+				``,
+			}, "\n")))
+		})
+	})
+})
+
+type testRecorder struct {
+	Labels []LabelSet
+}
+
+func (rcd *testRecorder) Record(labels LabelSet, elapsed time.Duration) {
+	rcd.Labels = append(rcd.Labels, labels)
+}
+
+type testRt struct {
+	Status int
+	Err    error
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	if rt.Err != nil {
+		err = rt.Err
+		return
+	}
+
+	response = &http.Response{
+		StatusCode: rt.Status,
+		Body:       io.NopCloser(strings.NewReader(`{"ima": "pc"}`)),
+		Request:    request,
+	}
+
+	return
+}
@@ -0,0 +1,388 @@
+package giant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MsgpackCodec implements Codec with MessagePack, honoring json tags and marshalers.
+// Byte slices are sent as base64 strings, as json would have it.
+type MsgpackCodec struct{}
+
+// ContentType returns application/msgpack.
+func (codec MsgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+// Encode encodes MessagePack.
+func (codec MsgpackCodec) Encode(obj any) (data []byte, err error) {
+
+	tree, err := toTree(obj)
+	if err != nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = writeMsgpack(buf, tree)
+	data = buf.Bytes()
+	return
+}
+
+// Decode decodes MessagePack.
+func (codec MsgpackCodec) Decode(data []byte, obj any) (err error) {
+
+	rdr := &binReader{data: data}
+
+	tree, err := readMsgpack(rdr)
+	if err != nil {
+		err = errors.Wrap(err, "failed to read msgpack")
+		return
+	}
+	if rdr.pos != len(data) {
+		err = errors.Errorf("unexpected %d bytes after msgpack value", len(data)-rdr.pos)
+		return
+	}
+
+	err = fromTree(tree, obj)
+	return
+}
+
+// unexported
+
+const (
+	mpNil      byte = 0xc0
+	mpFalse    byte = 0xc2
+	mpTrue     byte = 0xc3
+	mpBin8     byte = 0xc4
+	mpBin16    byte = 0xc5
+	mpBin32    byte = 0xc6
+	mpExt8     byte = 0xc7
+	mpExt16    byte = 0xc8
+	mpExt32    byte = 0xc9
+	mpFloat32  byte = 0xca
+	mpFloat64  byte = 0xcb
+	mpUint8    byte = 0xcc
+	mpUint16   byte = 0xcd
+	mpUint32   byte = 0xce
+	mpUint64   byte = 0xcf
+	mpInt8     byte = 0xd0
+	mpInt16    byte = 0xd1
+	mpInt32    byte = 0xd2
+	mpInt64    byte = 0xd3
+	mpFixExt1  byte = 0xd4
+	mpFixExt16 byte = 0xd8
+	mpStr8     byte = 0xd9
+	mpStr16    byte = 0xda
+	mpStr32    byte = 0xdb
+	mpArray16  byte = 0xdc
+	mpArray32  byte = 0xdd
+	mpMap16    byte = 0xde
+	mpMap32    byte = 0xdf
+
+	mpTimestamp int8 = -1
+)
+
+func writeMsgpack(buf *bytes.Buffer, tree any) (err error) {
+
+	switch val := tree.(type) {
+	case nil:
+		buf.WriteByte(mpNil)
+	case bool:
+		if val {
+			buf.WriteByte(mpTrue)
+		} else {
+			buf.WriteByte(mpFalse)
+		}
+	case int64:
+		writeMpInt(buf, val)
+	case uint64:
+		writeMpUint(buf, val)
+	case float64:
+		if float64(float32(val)) == val {
+			buf.WriteByte(mpFloat32)
+			buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(val))))
+		} else {
+			buf.WriteByte(mpFloat64)
+			buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(val)))
+		}
+	case string:
+		writeMpHead(buf, len(val), 0xa0, 32, mpStr8, mpStr16, mpStr32)
+		buf.WriteString(val)
+	case []byte:
+		writeMpHead(buf, len(val), 0, 0, mpBin8, mpBin16, mpBin32)
+		buf.Write(val)
+	case []any:
+		writeMpHead(buf, len(val), 0x90, 16, 0, mpArray16, mpArray32)
+		for _, item := range val {
+			err = writeMsgpack(buf, item)
+			if err != nil {
+				return
+			}
+		}
+	case members:
+		writeMpHead(buf, len(val), 0x80, 16, 0, mpMap16, mpMap32)
+		for _, mbr := range val {
+			writeMpHead(buf, len(mbr.key), 0xa0, 32, mpStr8, mpStr16, mpStr32)
+			buf.WriteString(mbr.key)
+			err = writeMsgpack(buf, mbr.val)
+			if err != nil {
+				return
+			}
+		}
+	default:
+		err = errors.Errorf("unsupported msgpack value: %T", tree)
+	}
+
+	return
+}
+
+// writeMpHead writes a fix, 8, 16, or 32 bit length header, skipping fix and 8 when zero.
+
+func writeMpHead(buf *bytes.Buffer, length int, fix byte, fixMax int, code8, code16, code32 byte) {
+
+	switch {
+	case fix != 0 && length < fixMax:
+		buf.WriteByte(fix | byte(length))
+	case code8 != 0 && length <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(length))
+	case length <= math.MaxUint16:
+		buf.WriteByte(code16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	default:
+		buf.WriteByte(code32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	}
+}
+
+func writeMpInt(buf *bytes.Buffer, val int64) {
+
+	switch {
+	case val >= 0:
+		writeMpUint(buf, uint64(val))
+	case val >= -32:
+		buf.WriteByte(byte(val))
+	case val >= math.MinInt8:
+		buf.WriteByte(mpInt8)
+		buf.WriteByte(byte(val))
+	case val >= math.MinInt16:
+		buf.WriteByte(mpInt16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(val)))
+	case val >= math.MinInt32:
+		buf.WriteByte(mpInt32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(val)))
+	default:
+		buf.WriteByte(mpInt64)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(val)))
+	}
+}
+
+func writeMpUint(buf *bytes.Buffer, val uint64) {
+
+	switch {
+	case val <= 0x7f:
+		buf.WriteByte(byte(val))
+	case val <= math.MaxUint8:
+		buf.WriteByte(mpUint8)
+		buf.WriteByte(byte(val))
+	case val <= math.MaxUint16:
+		buf.WriteByte(mpUint16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(val)))
+	case val <= math.MaxUint32:
+		buf.WriteByte(mpUint32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(val)))
+	default:
+		buf.WriteByte(mpUint64)
+		buf.Write(binary.BigEndian.AppendUint64(nil, val))
+	}
+}
+
+func readMsgpack(rdr *binReader) (tree any, err error) {
+
+	ascend, err := rdr.descend()
+	if err != nil {
+		return
+	}
+	defer ascend()
+
+	code, err := rdr.byte()
+	if err != nil {
+		return
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return readMpStr(rdr, uint64(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMpArray(rdr, uint64(code&0x0f))
+	case code&0xf0 == 0x80:
+		return readMpMap(rdr, uint64(code&0x0f))
+	case code >= mpFixExt1 && code <= mpFixExt16:
+		return readMpExt(rdr, 1<<(code-mpFixExt1))
+	}
+
+	switch code {
+	case mpNil:
+		return nil, nil
+	case mpFalse:
+		return false, nil
+	case mpTrue:
+		return true, nil
+	case mpFloat32:
+		var bits uint64
+		bits, err = rdr.uint(4)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case mpFloat64:
+		var bits uint64
+		bits, err = rdr.uint(8)
+		return math.Float64frombits(bits), err
+	case mpUint8, mpUint16, mpUint32, mpUint64:
+		return rdr.uint(1 << (code - mpUint8))
+	case mpInt8, mpInt16, mpInt32, mpInt64:
+		size := 1 << (code - mpInt8)
+		var bits uint64
+		bits, err = rdr.uint(size)
+		shift := 64 - 8*size
+		return int64(bits<<shift) >> shift, err
+	case mpStr8, mpStr16, mpStr32, mpBin8, mpBin16, mpBin32:
+		base := mpStr8
+		if code <= mpBin32 {
+			base = mpBin8
+		}
+		var length uint64
+		length, err = rdr.uint(1 << (code - base))
+		if err != nil {
+			return
+		}
+		if base == mpBin8 {
+			return rdr.bytes(length)
+		}
+		return readMpStr(rdr, length)
+	case mpArray16, mpArray32:
+		var length uint64
+		length, err = rdr.uint(2 << (code - mpArray16))
+		if err != nil {
+			return
+		}
+		return readMpArray(rdr, length)
+	case mpMap16, mpMap32:
+		var length uint64
+		length, err = rdr.uint(2 << (code - mpMap16))
+		if err != nil {
+			return
+		}
+		return readMpMap(rdr, length)
+	case mpExt8, mpExt16, mpExt32:
+		var length uint64
+		length, err = rdr.uint(1 << (code - mpExt8))
+		if err != nil {
+			return
+		}
+		return readMpExt(rdr, length)
+	}
+
+	err = errors.Errorf("unsupported msgpack code: 0x%02x", code)
+	return
+}
+
+func readMpStr(rdr *binReader, length uint64) (str string, err error) {
+
+	data, err := rdr.bytes(length)
+	str = string(data)
+	return
+}
+
+func readMpArray(rdr *binReader, length uint64) (array []any, err error) {
+
+	err = rdr.remaining(length)
+	if err != nil {
+		return
+	}
+
+	array = make([]any, 0, length)
+	for range length {
+		var item any
+		item, err = readMsgpack(rdr)
+		if err != nil {
+			return
+		}
+		array = append(array, item)
+	}
+
+	return
+}
+
+func readMpMap(rdr *binReader, length uint64) (mbrs members, err error) {
+
+	err = rdr.remaining(length)
+	if err != nil {
+		return
+	}
+
+	mbrs = make(members, 0, length)
+	for range length {
+		var key, val any
+		key, err = readMsgpack(rdr)
+		if err != nil {
+			return
+		}
+		val, err = readMsgpack(rdr)
+		if err != nil {
+			return
+		}
+
+		var str string
+		str, err = mapKey(key)
+		if err != nil {
+			return
+		}
+		mbrs = append(mbrs, member{key: str, val: val})
+	}
+
+	return
+}
+
+// readMpExt supports the timestamp extension only, returning time as RFC 3339.
+
+func readMpExt(rdr *binReader, length uint64) (val any, err error) {
+
+	extType, err := rdr.byte()
+	if err != nil {
+		return
+	}
+
+	data, err := rdr.bytes(length)
+	if err != nil {
+		return
+	}
+
+	if int8(extType) != mpTimestamp {
+		err = errors.Errorf("unsupported msgpack extension type: %d", int8(extType))
+		return
+	}
+
+	var tm time.Time
+	switch length {
+	case 4:
+		tm = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		bits := binary.BigEndian.Uint64(data)
+		tm = time.Unix(int64(bits&0x3ffffffff), int64(bits>>34))
+	case 12:
+		tm = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data)))
+	default:
+		err = errors.Errorf("unsupported msgpack timestamp length: %d", length)
+		return
+	}
+
+	val = tm.UTC().Format(time.RFC3339Nano)
+	return
+}
@@ -0,0 +1,113 @@
+package giant
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MsgpackCodec", func() {
+
+	var (
+		codec MsgpackCodec
+		data  []byte
+		err   error
+	)
+
+	Describe("encoding", func() {
+
+		It("honors json tags and keeps field order", func() {
+			data, err = codec.Encode(&binObj{Name: "a", Count: 1, Temps: []float64{1.5, -2}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hex.EncodeToString(data)).To(Equal(
+				"84" + "a46e616d65" + "a161" + // "name": "a"
+					"a5636f756e74" + "01" + // "count": 1
+					"a574656d7073" + "92" + "ca3fc00000" + "fe" + // "temps": [1.5, -2]
+					"a47768656e" + "c0", // "when": nil
+			))
+		})
+
+		It("sizes integers and strings", func() {
+			data, err = codec.Encode([]any{200, -200, 70000, strings.Repeat("x", 40)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hex.EncodeToString(data[:13])).To(Equal("94" + "ccc8" + "d1ff38" + "ce00011170" + "d928"))
+		})
+	})
+
+	Describe("round trip", func() {
+
+		It("decodes what it encodes, including marshalers", func() {
+			when := civil(time.Date(2023, 6, 14, 11, 0, 0, 0, time.UTC))
+			sent := &binObj{Name: "a", Count: -300, Temps: []float64{26.5, 1e100}, When: &when}
+
+			data, err = codec.Encode(sent)
+			Expect(err).ToNot(HaveOccurred())
+
+			rcvd := &binObj{}
+			err = codec.Decode(data, rcvd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvd).To(Equal(sent))
+		})
+	})
+
+	Describe("decoding", func() {
+
+		It("decodes timestamps as time", func() {
+			data, _ = hex.DecodeString("81" + "a47768656e" + "d6ff" + "6489a0f0")
+
+			rcvd := &struct {
+				When time.Time `json:"when"`
+			}{}
+			err = codec.Decode(data, rcvd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcvd.When).To(Equal(time.Unix(0x6489a0f0, 0).UTC()))
+		})
+
+		It("errors on truncated data", func() {
+			err = codec.Decode([]byte{0x92, 0x01}, &[]int{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("errors on absurd lengths", func() {
+			err = codec.Decode([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &[]int{})
+			Expect(err).To(MatchError(ContainSubstring("exceeds remaining")))
+		})
+
+		It("errors on deep nesting", func() {
+			// a million nested single item arrays around a nil
+			data = append(bytes.Repeat([]byte{0x91}, 1000000), 0xc0)
+
+			var rcvd any
+			err = codec.Decode(data, &rcvd)
+			Expect(err).To(MatchError(ContainSubstring("exceeded max depth of 10000")))
+		})
+	})
+})
+
+// help
+
+type binObj struct {
+	Name  string    `json:"name"`
+	Count int       `json:"count"`
+	Temps []float64 `json:"temps"`
+	When  *civil    `json:"when"`
+}
+
+// civil marshals itself as does svc.CivilTime
+
+type civil time.Time
+
+func (ct civil) MarshalJSON() ([]byte, error) {
+	return fmt.Appendf(nil, `"%s"`, time.Time(ct).Format("2006-01-02T15:04")), nil
+}
+
+func (ct *civil) UnmarshalJSON(data []byte) error {
+	tm, err := time.Parse("2006-01-02T15:04", strings.Trim(string(data), `"`))
+	*ct = civil(tm)
+	return err
+}
@@ -0,0 +1,182 @@
+package giant
+
+import (
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPartType string = "application/octet-stream"
+)
+
+// Multipart builds a multipart/form-data request whose parts are streamed
+// rather than buffered when the request is sent.
+type Multipart struct {
+	parts []Part
+}
+
+// Part represents the metadata of a multipart part, as logged in place of content.
+type Part struct {
+	// Name is the form field name.
+	Name string `json:"name"`
+	// Filename is set for file parts.
+	Filename string `json:"filename,omitempty"`
+	// ContentType is set for file parts.
+	ContentType string `json:"content_type,omitempty"`
+	// Value is set for plain field parts.
+	Value string `json:"value,omitempty"`
+
+	reader io.Reader
+}
+
+// Field adds a plain form field.
+func (mp *Multipart) Field(name, value string) *Multipart {
+
+	mp.parts = append(mp.parts, Part{Name: name, Value: value})
+	return mp
+}
+
+// File adds a file part, read from reader when the request is sent
+// and closed afterward if it is a Closer.
+// ContentType defaults to application/octet-stream when blank.
+func (mp *Multipart) File(name, filename, contentType string, reader io.Reader) *Multipart {
+
+	if contentType == "" {
+		contentType = defaultPartType
+	}
+
+	mp.parts = append(mp.parts, Part{
+		Name:        name,
+		Filename:    filename,
+		ContentType: contentType,
+		reader:      reader,
+	})
+	return mp
+}
+
+// Request returns a Request for use with Send, with the boundary set in Content-Type.
+func (mp *Multipart) Request(method, path string) Request {
+
+	reader, writer := io.Pipe()
+	body := &multipartBody{
+		parts:  mp.parts,
+		reader: reader,
+		pipe:   writer,
+		writer: multipart.NewWriter(writer),
+	}
+
+	return Request{
+		Method: method,
+		Path:   path,
+		Body:   body,
+		Headers: map[string]string{
+			"Content-Type": body.writer.FormDataContentType(),
+		},
+	}
+}
+
+// unexported
+
+// multipartBody writes parts into a pipe from a goroutine started on first read,
+// so nothing is left blocked when the request is never sent.
+
+type multipartBody struct {
+	parts  []Part
+	reader *io.PipeReader
+	pipe   *io.PipeWriter
+	writer *multipart.Writer
+	once   sync.Once
+}
+
+func (body *multipartBody) Read(data []byte) (int, error) {
+
+	body.once.Do(func() {
+		go body.write()
+	})
+
+	return body.reader.Read(data)
+}
+
+// Close closes the pipe, and file parts as well when writing never started.
+func (body *multipartBody) Close() (err error) {
+
+	err = body.reader.Close()
+
+	body.once.Do(func() {
+		closeParts(body.parts)
+	})
+	return
+}
+
+// Describe returns part metadata for logging in place of content.
+func (body *multipartBody) Describe() any {
+
+	return body.parts
+}
+
+func (body *multipartBody) write() {
+
+	var err error
+	for i, part := range body.parts {
+		err = body.writePart(part)
+		if err != nil {
+			closeParts(body.parts[i+1:])
+			break
+		}
+	}
+
+	if err == nil {
+		err = errors.Wrap(body.writer.Close(), "failed to close multipart writer")
+	}
+
+	body.pipe.CloseWithError(err)
+}
+
+func (body *multipartBody) writePart(part Part) (err error) {
+
+	if part.reader == nil {
+		err = body.writer.WriteField(part.Name, part.Value)
+		err = errors.Wrapf(err, "failed to write field %s", part.Name)
+		return
+	}
+
+	if closer, ok := part.reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+escapeQuotes(part.Name)+`"; filename="`+escapeQuotes(part.Filename)+`"`)
+	header.Set("Content-Type", part.ContentType)
+
+	writer, err := body.writer.CreatePart(header)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to create part %s", part.Name)
+		return
+	}
+
+	_, err = io.Copy(writer, part.reader)
+	err = errors.Wrapf(err, "failed to copy file %s into part %s", part.Filename, part.Name)
+	return
+}
+
+// closeParts closes the readers of file parts that are Closers.
+
+func closeParts(parts []Part) {
+
+	for _, part := range parts {
+		if closer, ok := part.reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(str string) string {
+	return quoteEscaper.Replace(str)
+}
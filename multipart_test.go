@@ -0,0 +1,161 @@
+package giant
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multipart", func() {
+
+	var (
+		srv      *httptest.Server
+		gnt      *Giant
+		mp       *Multipart
+		received map[string]string
+		files    map[string]string
+		ctype    string
+		err      error
+	)
+
+	BeforeEach(func() {
+		received = map[string]string{}
+		files = map[string]string{}
+
+		srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctype = request.Header.Get("Content-Type")
+
+			reader, err := request.MultipartReader()
+			Expect(err).ToNot(HaveOccurred())
+
+			for {
+				part, err := reader.NextPart()
+				if err != nil {
+					return
+				}
+
+				data, err := io.ReadAll(part)
+				if err != nil {
+					return
+				}
+
+				if part.FileName() != "" {
+					files[part.FileName()] = part.Header.Get("Content-Type") + ":" + string(data)
+					continue
+				}
+				received[part.FormName()] = string(data)
+			}
+		}))
+
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	JustBeforeEach(func() {
+		var response *http.Response
+		response, err = gnt.Send(context.Background(), mp.Request("POST", "/uploads/"))
+		if err == nil {
+			response.Body.Close()
+		}
+	})
+
+	When("sending fields and files", func() {
+		BeforeEach(func() {
+			mp = (&Multipart{}).
+				Field("title", "report").
+				File("doc", "report.txt", "text/plain", strings.NewReader("all is well")).
+				File("blob", "data.bin", "", strings.NewReader("\x00\x01"))
+		})
+
+		It("streams a multipart body", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctype).To(HavePrefix("multipart/form-data; boundary="))
+			Expect(received).To(Equal(map[string]string{"title": "report"}))
+			Expect(files).To(Equal(map[string]string{
+				"report.txt": "text/plain:all is well",
+				"data.bin":   "application/octet-stream:\x00\x01",
+			}))
+		})
+
+		It("describes parts without content", func() {
+			body, ok := mp.Request("POST", "/uploads/").Body.(interface{ Describe() any })
+			Expect(ok).To(BeTrue())
+			Expect(body.Describe()).To(Equal([]Part{
+				{Name: "title", Value: "report"},
+				{Name: "doc", Filename: "report.txt", ContentType: "text/plain", reader: mp.parts[1].reader},
+				{Name: "blob", Filename: "data.bin", ContentType: "application/octet-stream", reader: mp.parts[2].reader},
+			}))
+		})
+	})
+
+	When("a file fails to read", func() {
+		BeforeEach(func() {
+			mp = (&Multipart{}).File("doc", "report.txt", "", &failReader{})
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+
+		When("other files follow", func() {
+			var (
+				follower *closeReader
+			)
+
+			BeforeEach(func() {
+				follower = &closeReader{Reader: strings.NewReader("all is well")}
+				mp.File("log", "report.log", "", follower)
+			})
+
+			It("closes them", func() {
+				Expect(err).To(HaveOccurred())
+				Eventually(func() bool { return follower.Closed }).Should(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("Multipart body", func() {
+
+	It("closes files when closed before sending", func() {
+		first := &closeReader{Reader: strings.NewReader("all is well")}
+		second := &closeReader{Reader: strings.NewReader("all is swell")}
+
+		mp := (&Multipart{}).
+			Field("title", "report").
+			File("doc", "report.txt", "", first).
+			File("log", "report.log", "", second)
+
+		err := mp.Request("POST", "/uploads/").Body.(io.Closer).Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.Closed).To(BeTrue())
+		Expect(second.Closed).To(BeTrue())
+	})
+})
+
+type closeReader struct {
+	io.Reader
+	Closed bool
+}
+
+func (cr *closeReader) Close() error {
+	cr.Closed = true
+	return nil
+}
+
+type failReader struct{}
+
+func (fr *failReader) Read(data []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
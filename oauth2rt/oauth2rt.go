@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -17,7 +18,6 @@ import (
 // Todo: swith to New rather than direct construction.
 // Todo: no tripper logging etc here, prolly correct but doc
 // Todo: look at wrapping golang.org/x/oauth2 instead of hand-vibed soln
-// Todo: someday support form-encoded token requests (application/x-www-form-urlencoded)
 // Todo: someday proactive refresh via expires_in and/or adopt golang.org/x/oauth2
 
 // Logger specifies a contextual structured logger.
@@ -32,7 +32,9 @@ type OAuth2Rt struct {
 	TokenPath    string
 	ClientID     string
 	ClientSecret string
-	Logger       Logger
+	// Form sends the token request url-encoded rather than as json.
+	Form   bool
+	Logger Logger
 
 	mu    sync.RWMutex
 	token string
@@ -45,13 +47,16 @@ func (rt *OAuth2Rt) Wrap(next http.RoundTripper) {
 }
 
 // RoundTrip adds a Bearer token to requests, fetching lazily and retrying on 401.
+// Streamed bodies, those described for logging rather than read, are not buffered and so not retried.
 func (rt *OAuth2Rt) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	ctx := req.Context()
 
-	// buffer body for potential retry
+	// buffer body for potential retry, unless streamed
+	_, streamed := req.Body.(interface{ Describe() any })
+
 	var bodyBytes []byte
-	if req.Body != nil {
+	if req.Body != nil && !streamed {
 		var err error
 		bodyBytes, err = io.ReadAll(req.Body)
 		req.Body.Close()
@@ -73,8 +78,13 @@ func (rt *OAuth2Rt) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
-	// retry once on 401/403
+	// retry once on 401/403, or only clear the token when the body was streamed
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		if streamed {
+			rt.clearToken()
+			return resp, nil
+		}
+
 		rt.Logger.Info(ctx, "received 401/403, refreshing token")
 		resp.Body.Close()
 
@@ -141,20 +151,16 @@ func (rt *OAuth2Rt) refreshToken(ctx context.Context) (string, error) {
 	tokenURL := rt.BaseUri + rt.TokenPath
 	rt.Logger.Debug(ctx, "requesting oauth token", "url", tokenURL)
 
-	payload, err := json.Marshal(map[string]string{
-		"grant_type":    "client_credentials",
-		"client_id":     rt.ClientID,
-		"client_secret": rt.ClientSecret,
-	})
+	payload, contentType, err := rt.tokenPayload()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to marshal token request")
+		return "", err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewReader(payload))
 	if err != nil {
 		return "", errors.Wrap(err, "failed to create token request")
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := rt.next.RoundTrip(req)
 	if err != nil {
@@ -186,3 +192,26 @@ func (rt *OAuth2Rt) refreshToken(ctx context.Context) (string, error) {
 
 	return accessToken, nil
 }
+
+func (rt *OAuth2Rt) tokenPayload() (payload []byte, contentType string, err error) {
+
+	if rt.Form {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {rt.ClientID},
+			"client_secret": {rt.ClientSecret},
+		}
+		return []byte(form.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	payload, err = json.Marshal(map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     rt.ClientID,
+		"client_secret": rt.ClientSecret,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to marshal token request")
+	}
+
+	return payload, "application/json", nil
+}
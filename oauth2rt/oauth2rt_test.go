@@ -71,6 +71,35 @@ var _ = Describe("OAuth2Rt", func() {
 				})
 			})
 
+			When("token request is form-encoded", func() {
+				BeforeEach(func() {
+					mock = &mockRt{
+						TokenResponse: `{"access_token": "test-token-123"}`,
+						APIStatus:     200,
+					}
+
+					rt = &OAuth2Rt{
+						BaseUri:      "https://api.example.com",
+						TokenPath:    "/api/oauth",
+						ClientID:     "my-client",
+						ClientSecret: "my-secret",
+						Form:         true,
+						Logger:       &nopLogger{},
+					}
+					rt.Wrap(mock)
+
+					request, err = http.NewRequest("GET", "https://api.example.com/data", nil)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("posts form values for the token", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mock.LastAuthHeader).To(Equal("Bearer test-token-123"))
+					Expect(mock.TokenContentType).To(Equal("application/x-www-form-urlencoded"))
+					Expect(mock.TokenBody).To(Equal("client_id=my-client&client_secret=my-secret&grant_type=client_credentials"))
+				})
+			})
+
 			When("token is cached", func() {
 				BeforeEach(func() {
 					mock = &mockRt{
@@ -190,20 +219,63 @@ var _ = Describe("OAuth2Rt", func() {
 					Expect(mock.LastBody).To(Equal(`{"foo":"bar"}`))
 				})
 			})
+
+			When("api returns 401 on POST with streamed body", func() {
+				BeforeEach(func() {
+					mock = &mockRt{
+						TokenResponse:  `{"access_token": "fresh-token"}`,
+						APIStatus:      401,
+						RetryAPIStatus: 200,
+					}
+
+					rt = &OAuth2Rt{
+						BaseUri:      "https://api.example.com",
+						TokenPath:    "/api/oauth",
+						ClientID:     "my-client",
+						ClientSecret: "my-secret",
+						Logger:       &nopLogger{},
+						token:        "stale-token",
+					}
+					rt.Wrap(mock)
+
+					body := &streamedBody{ReadCloser: io.NopCloser(strings.NewReader(`{"foo":"bar"}`))}
+					request, err = http.NewRequest("POST", "https://api.example.com/data", body)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("passes the body through and clears the token without retrying", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response.StatusCode).To(Equal(401))
+					Expect(mock.APIRequests).To(Equal(1))
+					Expect(mock.LastBody).To(Equal(`{"foo":"bar"}`))
+					Expect(rt.token).To(BeEmpty())
+				})
+			})
 		})
 	})
 })
 
+// streamedBody is described for logging rather than read, as with multipart uploads
+type streamedBody struct {
+	io.ReadCloser
+}
+
+func (body *streamedBody) Describe() any {
+	return "streamed"
+}
+
 // mockRt simulates both token endpoint and API responses
 type mockRt struct {
 	TokenResponse  string
 	APIStatus      int
 	RetryAPIStatus int
 
-	TokenRequests  int
-	APIRequests    int
-	LastAuthHeader string
-	LastBody       string
+	TokenRequests    int
+	TokenContentType string
+	TokenBody        string
+	APIRequests      int
+	LastAuthHeader   string
+	LastBody         string
 }
 
 func (rt *mockRt) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -211,6 +283,9 @@ func (rt *mockRt) RoundTrip(req *http.Request) (*http.Response, error) {
 	// token endpoint
 	if req.URL.Path == "/api/oauth" {
 		rt.TokenRequests++
+		rt.TokenContentType = req.Header.Get("Content-Type")
+		body, _ := io.ReadAll(req.Body)
+		rt.TokenBody = string(body)
 		return &http.Response{
 			StatusCode: 200,
 			Body:       io.NopCloser(strings.NewReader(rt.TokenResponse)),
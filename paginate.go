@@ -0,0 +1,387 @@
+package giant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Pager specifies a pagination strategy.
+type Pager interface {
+	// First returns the path of the first page.
+	First(path string) (first string, err error)
+	// Next returns the path of the page following the one given, or blank when done.
+	Next(page Page) (next string, err error)
+}
+
+// Page represents a page as fetched during pagination.
+type Page struct {
+	// BaseUri is that of the client.
+	BaseUri string
+	// Path is the path the page was requested with.
+	Path string
+	// Header is the response header.
+	Header http.Header
+	// Body is the response body.
+	Body []byte
+	// Count is the number of items found in the page.
+	Count int
+}
+
+// Pagination represents options for Paginate.
+type Pagination struct {
+	// Pager is the pagination strategy.
+	Pager Pager
+	// ItemsKey is the key of the array holding items in each page
+	// (leave blank when the page is itself an array).
+	ItemsKey string
+	// MaxPages limits the number of pages fetched when non-zero.
+	MaxPages int
+}
+
+// Paginate iterates over items from a paginated endpoint,
+// sending a request per page via Send so that all trippers apply.
+// Iteration stops at the first error, which is yielded along with a zero item.
+func Paginate[T any](ctx context.Context, giant *Giant, path string, pgn Pagination) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+
+		var zero T
+
+		path, err := pgn.Pager.First(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for pages := 0; path != ""; pages++ {
+
+			if pgn.MaxPages > 0 && pages >= pgn.MaxPages {
+				return
+			}
+
+			err = ctx.Err()
+			if err != nil {
+				yield(zero, errors.Wrapf(err, "stopped paginating at %s", path))
+				return
+			}
+
+			var page Page
+			var items []T
+			page, items, err = fetchPage[T](ctx, giant, path, pgn.ItemsKey)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			var next string
+			next, err = pgn.Pager.Next(page)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if next == path {
+				yield(zero, errors.Errorf("pager returned the same path twice: %s", path))
+				return
+			}
+
+			path = next
+		}
+	}
+}
+
+// LinkPager follows RFC 8288 Link headers with rel="next".
+type LinkPager struct{}
+
+// First returns the path unchanged.
+func (pager LinkPager) First(path string) (first string, err error) {
+
+	first = path
+	return
+}
+
+// Next returns the path from the "next" link, if any.
+func (pager LinkPager) Next(page Page) (next string, err error) {
+
+	link := nextLink(page.Header.Values("Link"))
+	if link == "" {
+		return
+	}
+
+	next, err = resolvePath(page.BaseUri, page.Path, link)
+	return
+}
+
+// CursorPager follows a cursor token found in the response body.
+type CursorPager struct {
+	// Field is the key of the cursor in the body, dots descending into nested objects
+	// for example: meta.next_cursor
+	Field string
+	// Param is the query parameter the cursor is sent as.
+	Param string
+}
+
+// First returns the path unchanged.
+func (pager CursorPager) First(path string) (first string, err error) {
+
+	first = path
+	return
+}
+
+// Next returns the path with the cursor from the body, if any.
+func (pager CursorPager) Next(page Page) (next string, err error) {
+
+	// numbers are kept as sent, as cursors may be beyond float64 precision
+
+	decoder := json.NewDecoder(bytes.NewReader(page.Body))
+	decoder.UseNumber()
+
+	var obj any
+	err = decoder.Decode(&obj)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to decode page looking for cursor %s", pager.Field)
+		return
+	}
+
+	for _, key := range strings.Split(pager.Field, ".") {
+		fields, ok := obj.(map[string]any)
+		if !ok {
+			return
+		}
+		obj = fields[key]
+	}
+
+	var cursor string
+	switch val := obj.(type) {
+	case string:
+		cursor = val
+	case json.Number:
+		cursor = val.String()
+	}
+	if cursor == "" {
+		return
+	}
+
+	next, err = setQuery(page.Path, map[string]string{pager.Param: cursor})
+	return
+}
+
+// OffsetPager steps an offset query parameter by limit,
+// stopping when a page comes back short.
+type OffsetPager struct {
+	// Param is the offset query parameter.
+	Param string
+	// LimitParam is the limit query parameter.
+	LimitParam string
+	// Limit is the number of items requested per page.
+	Limit int
+}
+
+// First returns the path with offset zero and limit set.
+func (pager OffsetPager) First(path string) (first string, err error) {
+
+	first, err = setQuery(path, map[string]string{
+		pager.Param:      "0",
+		pager.LimitParam: strconv.Itoa(pager.Limit),
+	})
+	return
+}
+
+// Next returns the path with offset advanced by limit.
+func (pager OffsetPager) Next(page Page) (next string, err error) {
+
+	if page.Count == 0 || page.Count < pager.Limit {
+		return
+	}
+
+	offset, err := queryInt(page.Path, pager.Param)
+	if err != nil {
+		return
+	}
+
+	next, err = setQuery(page.Path, map[string]string{pager.Param: strconv.Itoa(offset + pager.Limit)})
+	return
+}
+
+// PagePager steps a page number query parameter by one,
+// stopping when a page comes back short.
+type PagePager struct {
+	// Param is the page number query parameter.
+	Param string
+	// SizeParam is the page size query parameter.
+	SizeParam string
+	// Size is the number of items requested per page.
+	Size int
+	// Start is the number of the first page, often 0 or 1.
+	Start int
+}
+
+// First returns the path with the starting page and size set.
+func (pager PagePager) First(path string) (first string, err error) {
+
+	first, err = setQuery(path, map[string]string{
+		pager.Param:     strconv.Itoa(pager.Start),
+		pager.SizeParam: strconv.Itoa(pager.Size),
+	})
+	return
+}
+
+// Next returns the path with the page number incremented.
+func (pager PagePager) Next(page Page) (next string, err error) {
+
+	if page.Count == 0 || page.Count < pager.Size {
+		return
+	}
+
+	number, err := queryInt(page.Path, pager.Param)
+	if err != nil {
+		return
+	}
+
+	next, err = setQuery(page.Path, map[string]string{pager.Param: strconv.Itoa(number + 1)})
+	return
+}
+
+// unexported
+
+func fetchPage[T any](ctx context.Context, giant *Giant, path, itemsKey string) (page Page, items []T, err error) {
+
+	response, body, err := giant.sendJson(ctx, "GET", path, nil)
+	if err != nil {
+		return
+	}
+
+	data := body
+	if itemsKey != "" {
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(body, &fields)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to decode page from %s", path)
+			return
+		}
+		data = fields[itemsKey]
+	}
+
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &items)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to decode items from %s", path)
+			return
+		}
+	}
+
+	page = Page{
+		BaseUri: giant.BaseUri,
+		Path:    path,
+		Header:  response.Header,
+		Body:    body,
+		Count:   len(items),
+	}
+	return
+}
+
+// nextLink finds the target of rel="next" among Link header values.
+
+func nextLink(values []string) (link string) {
+
+	for _, value := range values {
+		for _, part := range splitLinks(value) {
+
+			part = strings.TrimSpace(part)
+			end := strings.Index(part, ">")
+			if !strings.HasPrefix(part, "<") || end < 0 {
+				continue
+			}
+			target, params := part[1:end], part[end+1:]
+
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// splitLinks splits a Link header value on commas outside of <targets> and "quoted" params.
+
+func splitLinks(value string) (links []string) {
+
+	inTarget := false
+	inQuote := false
+	start := 0
+
+	for i, char := range value {
+		switch {
+		case char == '<' && !inQuote:
+			inTarget = true
+		case char == '>' && !inQuote:
+			inTarget = false
+		case char == '"' && !inTarget:
+			inQuote = !inQuote
+		case char == ',' && !inTarget && !inQuote:
+			links = append(links, value[start:i])
+			start = i + 1
+		}
+	}
+
+	links = append(links, value[start:])
+	return
+}
+
+func setQuery(path string, params map[string]string) (result string, err error) {
+
+	uri, err := url.Parse(path)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to parse path: %s", path)
+		return
+	}
+
+	query := uri.Query()
+	for key, val := range params {
+		query.Set(key, val)
+	}
+	uri.RawQuery = query.Encode()
+
+	result = uri.String()
+	return
+}
+
+func queryInt(path, key string) (val int, err error) {
+
+	uri, err := url.Parse(path)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to parse path: %s", path)
+		return
+	}
+
+	str := uri.Query().Get(key)
+	if str == "" {
+		return
+	}
+
+	val, err = strconv.Atoi(str)
+	err = errors.Wrapf(err, "failed to parse %s from %s", key, path)
+	return
+}
@@ -0,0 +1,251 @@
+package giant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paginate", func() {
+
+	var (
+		srv   *httptest.Server
+		gnt   *Giant
+		ctx   context.Context
+		pgn   Pagination
+		path  string
+		items []foo
+		paths []string
+		err   error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		items = nil
+		paths = nil
+		err = nil
+	})
+
+	JustBeforeEach(func() {
+		gnt = &Giant{
+			Client:  http.Client{},
+			BaseUri: srv.URL,
+		}
+
+		for item, itemErr := range Paginate[foo](ctx, gnt, path, pgn) {
+			if itemErr != nil {
+				err = itemErr
+				break
+			}
+			items = append(items, item)
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	When("following link headers", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{Pager: LinkPager{}}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				switch request.URL.Query().Get("page") {
+				case "":
+					writer.Header().Set("Link", `</things/?page=2>; rel="next", </things/?page=3>; rel="last"`)
+					fmt.Fprint(writer, `[{"data": "one"}, {"data": "two"}]`)
+				case "2":
+					writer.Header().Set("Link", fmt.Sprintf(`<http://%s/things/?page=3>; rel="next"`, request.Host))
+					fmt.Fprint(writer, `[{"data": "three"}]`)
+				default:
+					fmt.Fprint(writer, `[{"data": "four"}]`)
+				}
+			}))
+		})
+
+		It("yields items from every page", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/", "/things/?page=2", "/things/?page=3"}))
+			Expect(items).To(Equal([]foo{{Data: "one"}, {Data: "two"}, {Data: "three"}, {Data: "four"}}))
+		})
+
+		When("max pages is set", func() {
+			BeforeEach(func() {
+				pgn.MaxPages = 2
+			})
+
+			It("stops early", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(paths).To(HaveLen(2))
+				Expect(items).To(HaveLen(3))
+			})
+		})
+
+		When("context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("yields an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(paths).To(BeEmpty())
+				Expect(items).To(BeEmpty())
+			})
+		})
+	})
+
+	When("link targets have commas", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{Pager: LinkPager{}}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				if request.URL.Query().Get("page") == "" {
+					writer.Header().Set("Link", `</things/?page=9>; rel="last"; title="a, b", </things/?ids=1,2&page=2>; rel="next"`)
+				}
+				fmt.Fprint(writer, `[{"data": "one"}]`)
+			}))
+		})
+
+		It("keeps the target whole", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/", "/things/?ids=1,2&page=2"}))
+		})
+	})
+
+	When("next link is on a lookalike host", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{Pager: LinkPager{}}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				writer.Header().Set("Link", fmt.Sprintf(`<http://%s0/things/?page=2>; rel="next"`, request.Host))
+				fmt.Fprint(writer, `[{"data": "one"}]`)
+			}))
+		})
+
+		It("refuses to follow it", func() {
+			Expect(err).To(MatchError(ContainSubstring("is not under base uri")))
+			Expect(paths).To(HaveLen(1))
+			Expect(items).To(HaveLen(1))
+		})
+	})
+
+	When("following a cursor", func() {
+		BeforeEach(func() {
+			path = "/things/?sort=asc"
+			pgn = Pagination{
+				Pager:    CursorPager{Field: "meta.next", Param: "cursor"},
+				ItemsKey: "items",
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				switch request.URL.Query().Get("cursor") {
+				case "":
+					fmt.Fprint(writer, `{"items": [{"data": "one"}], "meta": {"next": "abc"}}`)
+				default:
+					fmt.Fprint(writer, `{"items": [{"data": "two"}], "meta": {"next": null}}`)
+				}
+			}))
+		})
+
+		It("yields items from every page", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/?sort=asc", "/things/?cursor=abc&sort=asc"}))
+			Expect(items).To(Equal([]foo{{Data: "one"}, {Data: "two"}}))
+		})
+	})
+
+	When("following a numeric cursor", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{
+				Pager:    CursorPager{Field: "next", Param: "cursor"},
+				ItemsKey: "items",
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				switch request.URL.Query().Get("cursor") {
+				case "":
+					fmt.Fprint(writer, `{"items": [{"data": "one"}], "next": 12345678901234567890123}`)
+				default:
+					fmt.Fprint(writer, `{"items": [{"data": "two"}]}`)
+				}
+			}))
+		})
+
+		It("sends it without loss of precision", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/", "/things/?cursor=12345678901234567890123"}))
+		})
+	})
+
+	When("stepping offset", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{
+				Pager: OffsetPager{Param: "offset", LimitParam: "limit", Limit: 2},
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				switch request.URL.Query().Get("offset") {
+				case "0":
+					fmt.Fprint(writer, `[{"data": "one"}, {"data": "two"}]`)
+				default:
+					fmt.Fprint(writer, `[{"data": "three"}]`)
+				}
+			}))
+		})
+
+		It("stops on a short page", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/?limit=2&offset=0", "/things/?limit=2&offset=2"}))
+			Expect(items).To(HaveLen(3))
+		})
+	})
+
+	When("stepping page number", func() {
+		BeforeEach(func() {
+			path = "/things/"
+			pgn = Pagination{
+				Pager: PagePager{Param: "page", SizeParam: "per_page", Size: 1, Start: 1},
+			}
+
+			srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.URL.RequestURI())
+
+				switch request.URL.Query().Get("page") {
+				case "1":
+					fmt.Fprint(writer, `[{"data": "one"}]`)
+				default:
+					fmt.Fprint(writer, `[]`)
+				}
+			}))
+		})
+
+		It("stops on an empty page", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/things/?page=1&per_page=1", "/things/?page=2&per_page=1"}))
+			Expect(items).To(Equal([]foo{{Data: "one"}}))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package giant
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// expandPath fills {name} placeholders in the path, ahead of any query, with escaped params.
+// The template is returned as well, for use as the route, blank when there are no placeholders.
+// Params of "." or "..", which would traverse once the path is normalized, are refused,
+// as are params without a placeholder.
+
+func expandPath(path string, params map[string]string) (expanded, template string, err error) {
+
+	template, query, hasQuery := strings.Cut(path, "?")
+
+	var builder strings.Builder
+	rest := template
+	used := map[string]bool{}
+
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		name := rest[start+1 : end]
+		if !validParam(name) {
+			builder.WriteString(rest[:end+1])
+			rest = rest[end+1:]
+			continue
+		}
+
+		value := params[name]
+		if value == "" {
+			err = errors.Errorf("path param %q not given for %s", name, path)
+			return
+		}
+		if value == "." || value == ".." {
+			err = errors.Errorf("path param %q may not be %q for %s", name, value, path)
+			return
+		}
+
+		builder.WriteString(rest[:start])
+		builder.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+		used[name] = true
+	}
+
+	if len(used) == 0 {
+		if len(params) > 0 {
+			err = errors.Errorf("path params given for %s without placeholders", path)
+		}
+		expanded, template = path, ""
+		return
+	}
+
+	for name := range params {
+		if !used[name] {
+			err = errors.Errorf("path param %q has no placeholder in %s", name, path)
+			return
+		}
+	}
+
+	builder.WriteString(rest)
+	expanded = builder.String()
+	if hasQuery {
+		expanded += "?" + query
+	}
+
+	return
+}
+
+func validParam(name string) bool {
+
+	if name == "" {
+		return false
+	}
+
+	for _, char := range name {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9', char == '_', char == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
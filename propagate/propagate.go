@@ -0,0 +1,153 @@
+// Package propagate provides http.Handler middleware carrying request id, trace context
+// and allowlisted baggage headers from inbound requests into context,
+// for giant trippers to forward on outbound calls.
+package propagate
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/clarktrimble/giant/logger"
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/tracert"
+)
+
+const (
+	defaultIdHeader string = "X-Request-Id"
+	maxIdLen        int    = 64
+)
+
+// Middleware extracts inbound request context.
+//
+// Outbound, the request id is sent by reqid.IdRt,
+// trace context by TraceRt, and baggage by BaggageRt.
+type Middleware struct {
+	// IdHeader is the inbound request id header, a new id is generated when not found.
+	IdHeader string
+	// Baggage are headers passed thru to upstreams, by canonical name.
+	Baggage map[string]bool
+	// Logger when set has request_id and trace_id added to context fields, optional.
+	Logger logger.Logger
+}
+
+// New creates a Middleware, with id header defaulting to X-Request-Id when blank.
+func New(idHeader string, baggage []string, lgr logger.Logger) (mw *Middleware) {
+
+	if idHeader == "" {
+		idHeader = defaultIdHeader
+	}
+
+	mw = &Middleware{
+		IdHeader: idHeader,
+		Baggage:  map[string]bool{},
+		Logger:   lgr,
+	}
+
+	for _, key := range baggage {
+		mw.Baggage[http.CanonicalHeaderKey(key)] = true
+	}
+
+	return
+}
+
+// Handler wraps next, serving it requests with context populated.
+func (mw *Middleware) Handler(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		next.ServeHTTP(writer, request.WithContext(mw.Context(request)))
+	})
+}
+
+// Context returns the request's context with request id, trace and baggage added.
+// Malformed inbound ids and trace context are ignored.
+func (mw *Middleware) Context(request *http.Request) (ctx context.Context) {
+
+	ctx = request.Context()
+	fields := []any{}
+
+	id := request.Header.Get(mw.IdHeader)
+	if !validId(id) {
+		id = reqid.New()
+	}
+	ctx = reqid.With(ctx, id)
+	fields = append(fields, "request_id", id)
+
+	trace, err := tracert.Parse(request.Header.Get(tracert.ParentHeader), request.Header.Get(tracert.StateHeader))
+	if err == nil {
+		ctx = tracert.WithTrace(ctx, trace)
+		fields = append(fields, "trace_id", trace.TraceId)
+	}
+
+	baggage := http.Header{}
+	for key := range mw.Baggage {
+		if values := request.Header.Values(key); len(values) > 0 {
+			baggage[key] = values
+		}
+	}
+	if len(baggage) > 0 {
+		ctx = WithBaggage(ctx, baggage)
+	}
+
+	if mw.Logger != nil {
+		ctx = mw.Logger.WithFields(ctx, fields...)
+	}
+
+	return
+}
+
+// WithBaggage returns a context carrying baggage headers.
+func WithBaggage(ctx context.Context, baggage http.Header) context.Context {
+
+	return context.WithValue(ctx, baggageKey{}, baggage)
+}
+
+// Baggage returns the baggage headers carried by the context, nil if none.
+func Baggage(ctx context.Context) http.Header {
+
+	baggage, _ := ctx.Value(baggageKey{}).(http.Header)
+	return baggage
+}
+
+// BaggageRt implements the Tripper interface, forwarding baggage headers from context.
+type BaggageRt struct {
+	next http.RoundTripper
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *BaggageRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip sets baggage headers not already set on the request.
+func (rt *BaggageRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	for key, values := range Baggage(request.Context()) {
+		if request.Header.Get(key) == "" {
+			request.Header[key] = values
+		}
+	}
+
+	response, err = rt.next.RoundTrip(request)
+	return
+}
+
+// unexported
+
+type baggageKey struct{}
+
+// validId guards against ids that are too long or would garble logs and headers.
+func validId(id string) bool {
+
+	if id == "" || len(id) > maxIdLen {
+		return false
+	}
+
+	for _, char := range id {
+		if char <= ' ' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
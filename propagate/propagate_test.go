@@ -0,0 +1,147 @@
+package propagate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clarktrimble/giant/reqid"
+	"github.com/clarktrimble/giant/tracert"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPropagate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Propagate Suite")
+}
+
+var _ = Describe("Propagate", func() {
+
+	Describe("middleware", func() {
+
+		var (
+			mw      *Middleware
+			lgr     *testLogger
+			inbound *http.Request
+			ctx     context.Context
+		)
+
+		BeforeEach(func() {
+			lgr = &testLogger{}
+			mw = New("", []string{"x-tenant-id"}, lgr)
+
+			inbound = httptest.NewRequest("GET", "/cardboard", nil)
+		})
+
+		JustBeforeEach(func() {
+			handler := mw.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				ctx = request.Context()
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), inbound)
+		})
+
+		When("headers are present", func() {
+			BeforeEach(func() {
+				inbound.Header.Set("X-Request-Id", "abc123")
+				inbound.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+				inbound.Header.Set("X-Tenant-Id", "boxworld")
+				inbound.Header.Set("X-Other", "not-allowed")
+			})
+
+			It("carries them in context", func() {
+				Expect(reqid.From(ctx)).To(Equal("abc123"))
+
+				trace, ok := tracert.FromContext(ctx)
+				Expect(ok).To(BeTrue())
+				Expect(trace.TraceId).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+
+				Expect(Baggage(ctx)).To(Equal(http.Header{"X-Tenant-Id": {"boxworld"}}))
+
+				Expect(lgr.Fields).To(Equal([]any{
+					"request_id", "abc123",
+					"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736",
+				}))
+			})
+		})
+
+		When("headers are absent or malformed", func() {
+			BeforeEach(func() {
+				inbound.Header.Set("X-Request-Id", "abc\n123")
+				inbound.Header.Set("traceparent", "garbage")
+			})
+
+			It("generates a request id and skips the rest", func() {
+				Expect(reqid.From(ctx)).To(HaveLen(7))
+
+				_, ok := tracert.FromContext(ctx)
+				Expect(ok).To(BeFalse())
+				Expect(Baggage(ctx)).To(BeNil())
+			})
+		})
+	})
+
+	Describe("baggage tripperware", func() {
+
+		var (
+			rt      *BaggageRt
+			request *http.Request
+			err     error
+		)
+
+		BeforeEach(func() {
+			rt = &BaggageRt{}
+			rt.Wrap(&testRt{Status: 200})
+
+			ctx := WithBaggage(context.Background(), http.Header{
+				"X-Tenant-Id": {"boxworld"},
+				"X-Region":    {"north"},
+			})
+			request, err = http.NewRequestWithContext(ctx, "GET", "https://boxworld.org/cardboard", nil)
+			Expect(err).ToNot(HaveOccurred())
+			request.Header.Set("X-Region", "south")
+
+			_, err = rt.RoundTrip(request)
+		})
+
+		It("forwards baggage not already set", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.Header.Get("X-Tenant-Id")).To(Equal("boxworld"))
+			Expect(request.Header.Get("X-Region")).To(Equal("south"))
+		})
+	})
+})
+
+type testLogger struct {
+	Fields []any
+}
+
+func (lgr *testLogger) Info(ctx context.Context, msg string, kv ...any)             {}
+func (lgr *testLogger) Debug(ctx context.Context, msg string, kv ...any)            {}
+func (lgr *testLogger) Trace(ctx context.Context, msg string, kv ...any)            {}
+func (lgr *testLogger) Error(ctx context.Context, msg string, err error, kv ...any) {}
+
+func (lgr *testLogger) WithFields(ctx context.Context, kv ...any) context.Context {
+	lgr.Fields = append(lgr.Fields, kv...)
+	return ctx
+}
+
+type testRt struct {
+	Status int
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	response = &http.Response{
+		StatusCode: rt.Status,
+		Body:       io.NopCloser(strings.NewReader(`{"ima": "pc"}`)),
+		Request:    request,
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+package giant
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EncodeQuery encodes a struct into query values per "query" field tags,
+// for example:
+//
+//	type ForecastQuery struct {
+//		Latitude  float64   `query:"latitude"`
+//		Hourly    []string  `query:"hourly,comma"`
+//		Start     time.Time `query:"start_date,date,omitempty"`
+//	}
+//
+// Options are as for EncodeForm.
+func EncodeQuery(obj any) (query url.Values, err error) {
+
+	query, err = encodeValues(obj, "query")
+	return
+}
+
+// EncodePath encodes a struct into path params per "path" field tags, such as `path:"id"`,
+// for use as Request.Params. Slices are comma-joined.
+// Options are otherwise as for EncodeForm.
+func EncodePath(obj any) (params map[string]string, err error) {
+
+	values, err := encodeValues(obj, "path")
+	if err != nil {
+		return
+	}
+
+	params = map[string]string{}
+	for name, strs := range values {
+		params[name] = strings.Join(strs, ",")
+	}
+
+	return
+}
@@ -0,0 +1,122 @@
+package giant
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+
+	Describe("encoding query values", func() {
+		var (
+			since time.Time
+			query url.Values
+			err   error
+		)
+
+		BeforeEach(func() {
+			since = time.Date(2023, 6, 14, 11, 30, 0, 0, time.UTC)
+		})
+
+		JustBeforeEach(func() {
+			query, err = EncodeQuery(&struct {
+				Latitude float64   `query:"latitude"`
+				Hourly   []string  `query:"hourly,comma"`
+				Tag      []string  `query:"tag"`
+				Start    time.Time `query:"start_date,date"`
+				Since    time.Time `query:"since,unix"`
+				SinceMs  time.Time `query:"since_ms,unixmilli"`
+				At       time.Time `query:"at" layout:"2006-01-02T15:04"`
+				Until    time.Time `query:"until,omitempty"`
+				Id       string    `path:"id"`
+			}{
+				Latitude: 58.38,
+				Hourly:   []string{"temperature_2m", "windspeed_10m"},
+				Tag:      []string{"a", "b"},
+				Start:    since,
+				Since:    since,
+				SinceMs:  since,
+				At:       since,
+				Id:       "abc",
+			})
+		})
+
+		It("encodes per tags and options", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query).To(Equal(url.Values{
+				"latitude":   {"58.38"},
+				"hourly":     {"temperature_2m,windspeed_10m"},
+				"tag":        {"a", "b"},
+				"start_date": {"2023-06-14"},
+				"since":      {"1686742200"},
+				"since_ms":   {"1686742200000"},
+				"at":         {"2023-06-14T11:30"},
+			}))
+		})
+	})
+
+	Describe("encoding path params", func() {
+		var (
+			params map[string]string
+			err    error
+		)
+
+		JustBeforeEach(func() {
+			params, err = EncodePath(struct {
+				Id    int      `path:"id"`
+				Names []string `path:"names"`
+				Limit int      `query:"limit"`
+			}{
+				Id:    123,
+				Names: []string{"a", "b"},
+				Limit: 10,
+			})
+		})
+
+		It("encodes per tags", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(params).To(Equal(map[string]string{
+				"id":    "123",
+				"names": "a,b",
+			}))
+		})
+	})
+
+	Describe("sending a request with query", func() {
+		var (
+			ts  *testServer
+			gnt *Giant
+			err error
+		)
+
+		BeforeEach(func() {
+			ts = newTestServer(`{"data": "thing2"}`)
+			gnt = &Giant{
+				Client:  http.Client{},
+				BaseUri: ts.Server.URL,
+			}
+		})
+
+		AfterEach(func() {
+			ts.Server.Close()
+		})
+
+		JustBeforeEach(func() {
+			_, err = gnt.Send(context.Background(), Request{
+				Path:   "/users/{id}?full=true",
+				Params: map[string]string{"id": "123"},
+				Query:  url.Values{"hourly": {"a,b"}},
+			})
+		})
+
+		It("appends the encoded query", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ts.Path).To(Equal("/users/123?full=true&hourly=a%2Cb"))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+package reqid
+
+import (
+	"net/http"
+)
+
+const (
+	defaultHeader string = "X-Request-Id"
+)
+
+// IdRt implements the Tripper interface, sending the request id upstream in a header.
+// The id is taken from context when found there, as added by LogRt, and generated and added otherwise.
+type IdRt struct {
+	// Header is the header the id is sent in, X-Request-Id when blank.
+	Header string
+	next   http.RoundTripper
+}
+
+// NewRt creates an IdRt.
+func NewRt(header string) *IdRt {
+
+	return &IdRt{Header: header}
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *IdRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip adds the request id header, unless already set.
+func (rt *IdRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	header := rt.Header
+	if header == "" {
+		header = defaultHeader
+	}
+
+	ctx, id := Ensure(request.Context())
+	request = request.WithContext(ctx)
+
+	if request.Header.Get(header) == "" {
+		request.Header.Set(header, id)
+	}
+
+	response, err = rt.next.RoundTrip(request)
+	return
+}
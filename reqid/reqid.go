@@ -0,0 +1,47 @@
+// Package reqid carries a request id in context, for logging and propagation upstream.
+package reqid
+
+import (
+	"context"
+
+	"github.com/clarktrimble/hondo"
+)
+
+const (
+	idLen int = 7
+)
+
+// New generates a request id.
+func New() string {
+
+	return hondo.Rand(idLen)
+}
+
+// With returns a context carrying the request id.
+func With(ctx context.Context, id string) context.Context {
+
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// From returns the request id carried by the context, blank if none.
+func From(ctx context.Context) string {
+
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Ensure returns the context and the request id it carries, generating and adding one if needed.
+func Ensure(ctx context.Context) (context.Context, string) {
+
+	id := From(ctx)
+	if id != "" {
+		return ctx, id
+	}
+
+	id = New()
+	return With(ctx, id), id
+}
+
+// unexported
+
+type idKey struct{}
@@ -0,0 +1,132 @@
+package reqid
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReqId(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReqId Suite")
+}
+
+var _ = Describe("ReqId", func() {
+
+	Describe("ensuring an id", func() {
+
+		var (
+			ctx    context.Context
+			ensCtx context.Context
+			id     string
+		)
+
+		JustBeforeEach(func() {
+			ensCtx, id = Ensure(ctx)
+		})
+
+		When("context has an id", func() {
+			BeforeEach(func() {
+				ctx = With(context.Background(), "abc1234")
+			})
+
+			It("returns it", func() {
+				Expect(id).To(Equal("abc1234"))
+				Expect(ensCtx).To(Equal(ctx))
+			})
+		})
+
+		When("context has no id", func() {
+			BeforeEach(func() {
+				ctx = context.Background()
+			})
+
+			It("generates and adds one", func() {
+				Expect(id).To(HaveLen(7))
+				Expect(From(ensCtx)).To(Equal(id))
+				Expect(From(ctx)).To(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("IdRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt      *IdRt
+			trt     *testRt
+			request *http.Request
+			err     error
+		)
+
+		BeforeEach(func() {
+			rt = NewRt("")
+			trt = &testRt{}
+			rt.Wrap(trt)
+
+			request, err = http.NewRequest("GET", "https://boxworld.org/cardboard", nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		JustBeforeEach(func() {
+			_, err = rt.RoundTrip(request)
+		})
+
+		When("request id is in context", func() {
+			BeforeEach(func() {
+				request = request.WithContext(With(context.Background(), "abc1234"))
+			})
+
+			It("sends it", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(trt.Request.Header.Get("X-Request-Id")).To(Equal("abc1234"))
+			})
+		})
+
+		When("request id is not in context", func() {
+			It("generates, sends and adds it to context for those wrapped", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				id := trt.Request.Header.Get("X-Request-Id")
+				Expect(id).To(HaveLen(7))
+				Expect(From(trt.Request.Context())).To(Equal(id))
+			})
+		})
+
+		When("header is configured and already set", func() {
+			BeforeEach(func() {
+				rt.Header = "X-Correlation-Id"
+				request.Header.Set("X-Correlation-Id", "upstream")
+			})
+
+			It("leaves it be", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(trt.Request.Header.Get("X-Correlation-Id")).To(Equal("upstream"))
+				Expect(trt.Request.Header.Get("X-Request-Id")).To(BeEmpty())
+			})
+		})
+	})
+})
+
+type testRt struct {
+	Request *http.Request
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	rt.Request = request
+
+	response = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"ima": "pc"}`)),
+		Request:    request,
+	}
+	return
+}
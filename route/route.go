@@ -0,0 +1,105 @@
+// Package route carries a route template in context, ex: /users/{id}/orders/{oid},
+// for metrics, logging and tracing to use in place of raw paths, keeping cardinality bounded.
+package route
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// With returns a context carrying the route template.
+func With(ctx context.Context, route string) context.Context {
+
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// From returns the route template carried by the context, blank if none.
+func From(ctx context.Context) string {
+
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+// Of returns the route template for a request, falling back to its path.
+func Of(request *http.Request) string {
+
+	route := From(request.Context())
+	if route == "" {
+		route = request.URL.Path
+	}
+
+	return route
+}
+
+// Matcher derives route templates from paths.
+type Matcher struct {
+	// Fallback is returned for paths matching no template, ex: "other", optional.
+	Fallback  string
+	templates []template
+}
+
+// NewMatcher creates a Matcher from templates, tried in order.
+// Placeholders in braces match any one non-empty segment.
+func NewMatcher(templates ...string) (mt *Matcher) {
+
+	mt = &Matcher{}
+	for _, tmpl := range templates {
+		mt.templates = append(mt.templates, template{
+			route:    tmpl,
+			segments: strings.Split(tmpl, "/"),
+		})
+	}
+
+	return
+}
+
+// Match returns the first template matching the path, ignoring any query,
+// or Fallback when none do.
+func (mt *Matcher) Match(path string) string {
+
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+
+	for _, tmpl := range mt.templates {
+		if tmpl.match(segments) {
+			return tmpl.route
+		}
+	}
+
+	return mt.Fallback
+}
+
+// unexported
+
+type routeKey struct{}
+
+type template struct {
+	route    string
+	segments []string
+}
+
+func (tmpl template) match(segments []string) bool {
+
+	if len(segments) != len(tmpl.segments) {
+		return false
+	}
+
+	for i, segment := range tmpl.segments {
+		switch {
+		case isPlaceholder(segment):
+			if segments[i] == "" {
+				return false
+			}
+		case segment != segments[i]:
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPlaceholder(segment string) bool {
+
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
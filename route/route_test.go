@@ -0,0 +1,77 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRoute(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Route Suite")
+}
+
+var _ = Describe("Route", func() {
+
+	Describe("matching paths", func() {
+
+		var (
+			mt *Matcher
+		)
+
+		BeforeEach(func() {
+			mt = NewMatcher(
+				"/users/me",
+				"/users/{id}",
+				"/users/{id}/orders/{oid}",
+			)
+		})
+
+		It("returns the first matching template", func() {
+			Expect(mt.Match("/users/me")).To(Equal("/users/me"))
+			Expect(mt.Match("/users/123")).To(Equal("/users/{id}"))
+			Expect(mt.Match("/users/123/orders/456?full=true")).To(Equal("/users/{id}/orders/{oid}"))
+		})
+
+		It("does not match empty segments or other paths", func() {
+			Expect(mt.Match("/users/")).To(BeEmpty())
+			Expect(mt.Match("/users/123/orders")).To(BeEmpty())
+			Expect(mt.Match("/posts/123")).To(BeEmpty())
+		})
+
+		When("fallback is set", func() {
+			BeforeEach(func() {
+				mt.Fallback = "other"
+			})
+
+			It("returns it for unmatched paths", func() {
+				Expect(mt.Match("/posts/123")).To(Equal("other"))
+			})
+		})
+	})
+
+	Describe("getting route of a request", func() {
+
+		var (
+			request *http.Request
+		)
+
+		BeforeEach(func() {
+			var err error
+			request, err = http.NewRequest("GET", "https://boxworld.org/users/123", nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("falls back to the path", func() {
+			Expect(Of(request)).To(Equal("/users/123"))
+		})
+
+		It("prefers the route in context", func() {
+			request = request.WithContext(With(context.Background(), "/users/{id}"))
+			Expect(Of(request)).To(Equal("/users/{id}"))
+		})
+	})
+})
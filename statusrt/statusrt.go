@@ -3,6 +3,7 @@
 package statusrt
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 
@@ -25,6 +26,20 @@ const (
 	maxStatus int = 300
 )
 
+// StatusError represents a response with unexpected status code.
+type StatusError struct {
+	// Code is the response status code.
+	Code int
+	// Body is the response body.
+	Body []byte
+}
+
+// Error implements the error interface.
+func (err *StatusError) Error() string {
+
+	return fmt.Sprintf("unexpected status code %d with body: %s", err.Code, err.Body)
+}
+
 // StatusRt implements RoundTripper.
 type StatusRt struct {
 	next http.RoundTripper
@@ -35,7 +50,7 @@ func (rt *StatusRt) Wrap(next http.RoundTripper) {
 	rt.next = next
 }
 
-// RoundTrip returns an error if the status code is bad.
+// RoundTrip returns an error if the status code is bad, a StatusError when the body can be read.
 func (rt *StatusRt) RoundTrip(request *http.Request) (*http.Response, error) {
 
 	response, err := rt.next.RoundTrip(request)
@@ -48,7 +63,7 @@ func (rt *StatusRt) RoundTrip(request *http.Request) (*http.Response, error) {
 		if readErr != nil {
 			err = errors.Wrapf(readErr, "somehow failed to read body after unexpected status code %d", response.StatusCode)
 		} else {
-			err = errors.Errorf("unexpected status code %d with body: %s", response.StatusCode, body)
+			err = &StatusError{Code: response.StatusCode, Body: body}
 		}
 		response.Body.Close()
 		return nil, err
@@ -65,7 +65,8 @@ var _ = Describe("StatusRt", func() {
 				})
 				It("returns an error", func() {
 
-					Expect(err).To(HaveOccurred())
+					Expect(err).To(MatchError(&StatusError{Code: 404, Body: []byte(`{"ima": "pc"}`)}))
+					Expect(err).To(MatchError(`unexpected status code 404 with body: {"ima": "pc"}`))
 					Expect(response).To(BeNil())
 				})
 			})
@@ -0,0 +1,206 @@
+// Package tracert implements the Tripper interface
+// propagating W3C trace context and recording client spans.
+package tracert
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/clarktrimble/giant/route"
+	"github.com/pkg/errors"
+)
+
+// see https://www.w3.org/TR/trace-context/
+
+const (
+	ParentHeader string = "traceparent"
+	StateHeader  string = "tracestate"
+
+	version     string = "00"
+	sampledFlag byte   = 0x01
+	traceIdLen  int    = 16
+	spanIdLen   int    = 8
+)
+
+// Trace represents a W3C trace context.
+type Trace struct {
+	// TraceId is 32 lowercase hex chars.
+	TraceId string
+	// SpanId is 16 lowercase hex chars, the parent of the next span.
+	SpanId string
+	// Flags are trace flags, sampled or not.
+	Flags byte
+	// State is vendor specific tracestate, passed thru as is.
+	State string
+}
+
+// Span represents a client span.
+type Span struct {
+	Name       string
+	TraceId    string
+	SpanId     string
+	ParentId   string
+	Start      time.Time
+	Duration   time.Duration
+	Attributes map[string]any
+	Err        error
+}
+
+// Tracer specifies a backend that spans are recorded with.
+type Tracer interface {
+	Record(ctx context.Context, span Span)
+}
+
+// TraceRt implements the Tripper interface.
+type TraceRt struct {
+	// Tracer records spans, optional.
+	Tracer Tracer
+	next   http.RoundTripper
+}
+
+// New creates a TraceRt.
+func New(tracer Tracer) *TraceRt {
+
+	return &TraceRt{Tracer: tracer}
+}
+
+// Parse parses traceparent and tracestate header values.
+func Parse(parent, state string) (trace Trace, err error) {
+
+	parts := strings.Split(strings.TrimSpace(parent), "-")
+	if len(parts) < 4 || parts[0] == "ff" || len(parts[0]) != 2 || (parts[0] == version && len(parts) != 4) {
+		err = errors.Errorf("invalid traceparent: %s", parent)
+		return
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 || !validId(parts[1], traceIdLen) || !validId(parts[2], spanIdLen) {
+		err = errors.Errorf("invalid traceparent: %s", parent)
+		return
+	}
+
+	trace = Trace{
+		TraceId: parts[1],
+		SpanId:  parts[2],
+		Flags:   flags[0],
+		State:   strings.TrimSpace(state),
+	}
+	return
+}
+
+// Parent formats traceparent.
+func (trace Trace) Parent() string {
+
+	return fmt.Sprintf("%s-%s-%s-%02x", version, trace.TraceId, trace.SpanId, trace.Flags)
+}
+
+// Sampled reports whether the sampled flag is set.
+func (trace Trace) Sampled() bool {
+
+	return trace.Flags&sampledFlag != 0
+}
+
+// WithTrace returns a context carrying a trace, as received from upstream.
+func WithTrace(ctx context.Context, trace Trace) context.Context {
+
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// FromContext returns the trace carried by the context, if any.
+func FromContext(ctx context.Context) (trace Trace, ok bool) {
+
+	trace, ok = ctx.Value(traceKey{}).(Trace)
+	return
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *TraceRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip injects trace headers for a new span, continuing the trace in context
+// or starting one, and records the span.
+func (rt *TraceRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	ctx := request.Context()
+
+	parent, ok := FromContext(ctx)
+	if !ok {
+		parent = Trace{TraceId: randomId(traceIdLen), Flags: sampledFlag}
+	}
+
+	span := Span{
+		Name:     request.Method,
+		TraceId:  parent.TraceId,
+		SpanId:   randomId(spanIdLen),
+		ParentId: parent.SpanId,
+		Start:    time.Now(),
+		Attributes: map[string]any{
+			"http.request.method": request.Method,
+			"server.address":      request.URL.Hostname(),
+			"url.path":            request.URL.Path,
+		},
+	}
+
+	// named for the route template only, as paths are high cardinality
+	if rte := route.From(ctx); rte != "" {
+		span.Name = fmt.Sprintf("%s %s", request.Method, rte)
+		span.Attributes["url.template"] = rte
+	}
+
+	child := parent
+	child.SpanId = span.SpanId
+
+	request.Header.Set(ParentHeader, child.Parent())
+	if child.State != "" {
+		request.Header.Set(StateHeader, child.State)
+	}
+
+	response, err = rt.next.RoundTrip(request)
+
+	if rt.Tracer == nil || !child.Sampled() {
+		return
+	}
+
+	span.Duration = time.Since(span.Start)
+	span.Err = err
+	if response != nil {
+		span.Attributes["http.response.status_code"] = response.StatusCode
+	}
+
+	rt.Tracer.Record(ctx, span)
+	return
+}
+
+// unexported
+
+type traceKey struct{}
+
+func randomId(size int) string {
+
+	data := make([]byte, size)
+	_, _ = rand.Read(data)
+
+	return hex.EncodeToString(data)
+}
+
+// validId checks for lowercase hex of byte size given that's not all zeros.
+func validId(id string, size int) bool {
+
+	if len(id) != size*2 || strings.Trim(id, "0") == "" {
+		return false
+	}
+
+	for _, char := range id {
+		if !strings.ContainsRune("0123456789abcdef", char) {
+			return false
+		}
+	}
+
+	return true
+}
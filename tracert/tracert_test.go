@@ -0,0 +1,161 @@
+package tracert
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/clarktrimble/giant/route"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTraceRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TraceRt Suite")
+}
+
+var _ = Describe("TraceRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt      *TraceRt
+			tracer  *testTracer
+			request *http.Request
+			ctx     context.Context
+			err     error
+		)
+
+		BeforeEach(func() {
+			tracer = &testTracer{}
+			rt = New(tracer)
+			rt.Wrap(&testRt{Status: 201})
+
+			ctx = context.Background()
+		})
+
+		JustBeforeEach(func() {
+			request, err = http.NewRequestWithContext(ctx, "PUT", "https://boxworld.org/cardboard", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = rt.RoundTrip(request)
+		})
+
+		When("trace is in context", func() {
+			BeforeEach(func() {
+				trace, err := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+				Expect(err).ToNot(HaveOccurred())
+				ctx = WithTrace(ctx, trace)
+			})
+
+			It("continues the trace and records a span", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(tracer.Spans).To(HaveLen(1))
+				span := tracer.Spans[0]
+				Expect(span.TraceId).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+				Expect(span.ParentId).To(Equal("00f067aa0ba902b7"))
+				Expect(span.SpanId).To(HaveLen(16))
+				Expect(span.Duration).To(BeNumerically(">", 0))
+				Expect(span.Attributes).To(Equal(map[string]any{
+					"http.request.method":       "PUT",
+					"server.address":            "boxworld.org",
+					"url.path":                  "/cardboard",
+					"http.response.status_code": 201,
+				}))
+
+				Expect(request.Header.Get("traceparent")).To(Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanId + "-01"))
+				Expect(request.Header.Get("tracestate")).To(Equal("congo=t61rcWkgMzE"))
+			})
+		})
+
+		When("route is in context", func() {
+			BeforeEach(func() {
+				ctx = route.With(ctx, "/{material}")
+			})
+
+			It("names the span for it", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(tracer.Spans).To(HaveLen(1))
+				Expect(tracer.Spans[0].Name).To(Equal("PUT /{material}"))
+				Expect(tracer.Spans[0].Attributes).To(HaveKeyWithValue("url.template", "/{material}"))
+			})
+		})
+
+		When("trace is not sampled", func() {
+			BeforeEach(func() {
+				trace, err := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+				Expect(err).ToNot(HaveOccurred())
+				ctx = WithTrace(ctx, trace)
+			})
+
+			It("propagates without recording", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tracer.Spans).To(BeEmpty())
+				Expect(request.Header.Get("traceparent")).To(HaveSuffix("-00"))
+			})
+		})
+
+		When("trace is not in context", func() {
+			It("starts a trace", func() {
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(tracer.Spans).To(HaveLen(1))
+				span := tracer.Spans[0]
+				Expect(span.TraceId).To(HaveLen(32))
+				Expect(span.ParentId).To(BeEmpty())
+
+				trace, err := Parse(request.Header.Get("traceparent"), "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(trace.TraceId).To(Equal(span.TraceId))
+				Expect(trace.SpanId).To(Equal(span.SpanId))
+				Expect(trace.Sampled()).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("parsing traceparent", func() {
+
+		It("rejects invalid values", func() {
+			for _, parent := range []string{
+				"",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			} {
+				_, err := Parse(parent, "")
+				Expect(err).To(HaveOccurred(), parent)
+			}
+		})
+	})
+})
+
+type testTracer struct {
+	Spans []Span
+}
+
+func (tt *testTracer) Record(ctx context.Context, span Span) {
+	tt.Spans = append(tt.Spans, span)
+}
+
+type testRt struct {
+	Status int
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	response = &http.Response{
+		StatusCode: rt.Status,
+		Body:       io.NopCloser(strings.NewReader(`{"ima": "pc"}`)),
+		Request:    request,
+	}
+
+	return
+}
@@ -0,0 +1,272 @@
+package giant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Binary codecs go by way of json so that json struct tags and marshalers
+// are honored as is, with a generic tree of values in between:
+//
+//   - encoding: object -> json -> tree -> binary
+//   - decoding: binary -> tree -> json -> object
+//
+// Trees hold nil, bool, int64, uint64, float64, string, []byte, []any and members (maps in key order).
+
+// member is a key and value from a map.
+type member struct {
+	key string
+	val any
+}
+
+// members is a map preserving key order.
+type members []member
+
+// MarshalJSON implements the Marshaler interface, keeping key order.
+func (mbrs members) MarshalJSON() (data []byte, err error) {
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+
+	for i, mbr := range mbrs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		var key, val []byte
+		key, err = json.Marshal(mbr.key)
+		if err != nil {
+			return
+		}
+		val, err = json.Marshal(mbr.val)
+		if err != nil {
+			return
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+
+	buf.WriteByte('}')
+	data = buf.Bytes()
+	return
+}
+
+// toTree marshals an object to json and parses it into a tree.
+
+func toTree(obj any) (tree any, err error) {
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	tree, err = parseTree(decoder)
+	return
+}
+
+// fromTree renders a tree as json and unmarshals it into an object.
+
+func fromTree(tree, obj any) (err error) {
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		err = errors.Wrap(err, "failed to render decoded value as json")
+		return
+	}
+
+	err = json.Unmarshal(data, obj)
+	return
+}
+
+func parseTree(decoder *json.Decoder) (tree any, err error) {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+
+	switch tkn := token.(type) {
+	case json.Delim:
+		switch tkn {
+		case '[':
+			tree, err = parseArray(decoder)
+		case '{':
+			tree, err = parseObject(decoder)
+		default:
+			err = errors.Errorf("unexpected json delimiter: %s", tkn)
+		}
+	case json.Number:
+		tree, err = parseNumber(tkn)
+	default:
+		// string, bool, or nil
+		tree = tkn
+	}
+
+	return
+}
+
+func parseArray(decoder *json.Decoder) (array []any, err error) {
+
+	array = []any{}
+	for decoder.More() {
+		var val any
+		val, err = parseTree(decoder)
+		if err != nil {
+			return
+		}
+		array = append(array, val)
+	}
+
+	_, err = decoder.Token()
+	return
+}
+
+func parseObject(decoder *json.Decoder) (mbrs members, err error) {
+
+	mbrs = members{}
+	for decoder.More() {
+		var token json.Token
+		token, err = decoder.Token()
+		if err != nil {
+			return
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			err = errors.Errorf("unexpected json object key: %v", token)
+			return
+		}
+
+		var val any
+		val, err = parseTree(decoder)
+		if err != nil {
+			return
+		}
+		mbrs = append(mbrs, member{key: key, val: val})
+	}
+
+	_, err = decoder.Token()
+	return
+}
+
+// parseNumber prefers int64, then uint64, then float64.
+
+func parseNumber(num json.Number) (val any, err error) {
+
+	str := num.String()
+	if !strings.ContainsAny(str, ".eE") {
+		if intVal, intErr := strconv.ParseInt(str, 10, 64); intErr == nil {
+			return intVal, nil
+		}
+		if uintVal, uintErr := strconv.ParseUint(str, 10, 64); uintErr == nil {
+			return uintVal, nil
+		}
+	}
+
+	val, err = strconv.ParseFloat(str, 64)
+	err = errors.Wrapf(err, "failed to parse number: %s", str)
+	return
+}
+
+// mapKey renders a decoded map key as a string, as json requires.
+
+func mapKey(key any) (str string, err error) {
+
+	switch val := key.(type) {
+	case string:
+		str = val
+	case []byte:
+		str = string(val)
+	case int64, uint64, float64, bool:
+		str = fmt.Sprint(val)
+	default:
+		err = errors.Errorf("unsupported map key type: %T", key)
+	}
+
+	return
+}
+
+// binReader reads from a byte slice, erroring rather than panicking when short.
+// It tracks the depth of nested values as well, for descend to limit.
+
+type binReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// maxDepth limits nesting of decoded values, as encoding/json does,
+// so that hostile input errors rather than overflowing the stack.
+const maxDepth int = 10000
+
+func (rdr *binReader) byte() (val byte, err error) {
+
+	if rdr.pos >= len(rdr.data) {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+
+	val = rdr.data[rdr.pos]
+	rdr.pos++
+	return
+}
+
+func (rdr *binReader) bytes(count uint64) (val []byte, err error) {
+
+	if count > uint64(len(rdr.data)-rdr.pos) {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+
+	val = rdr.data[rdr.pos : rdr.pos+int(count)]
+	rdr.pos += int(count)
+	return
+}
+
+func (rdr *binReader) uint(size int) (val uint64, err error) {
+
+	data, err := rdr.bytes(uint64(size))
+	if err != nil {
+		return
+	}
+
+	for _, bt := range data {
+		val = val<<8 | uint64(bt)
+	}
+	return
+}
+
+// remaining guards allocations by declared counts, each item taking at least a byte.
+
+func (rdr *binReader) remaining(count uint64) (err error) {
+
+	if count > uint64(len(rdr.data)-rdr.pos) {
+		err = errors.Errorf("declared count %d exceeds remaining data", count)
+	}
+	return
+}
+
+// descend enters a value, erroring when nested too deeply, and returns a func to leave it.
+
+func (rdr *binReader) descend() (ascend func(), err error) {
+
+	if rdr.depth >= maxDepth {
+		err = errors.Errorf("exceeded max depth of %d", maxDepth)
+		return
+	}
+
+	rdr.depth++
+	ascend = func() { rdr.depth-- }
+	return
+}
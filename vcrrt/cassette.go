@@ -0,0 +1,121 @@
+package vcrrt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette holds recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method" yaml:"method"`
+	Url    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   Body        `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status" yaml:"status"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   Body        `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Body is recorded as text when valid utf-8 and base64 encoded otherwise.
+type Body struct {
+	Text   string `json:"text,omitempty" yaml:"text,omitempty"`
+	Base64 string `json:"base64,omitempty" yaml:"base64,omitempty"`
+}
+
+// Load reads a cassette from a json or yaml file, per its extension.
+func Load(path string) (cassette *Cassette, err error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read cassette")
+		return
+	}
+
+	cassette = &Cassette{}
+	if isYaml(path) {
+		err = yaml.Unmarshal(data, cassette)
+	} else {
+		err = json.Unmarshal(data, cassette)
+	}
+	err = errors.Wrapf(err, "failed to decode cassette: %s", path)
+	return
+}
+
+// Save writes the cassette to a json or yaml file, per its extension, creating directories as needed.
+func (cassette *Cassette) Save(path string) (err error) {
+
+	var data []byte
+	if isYaml(path) {
+		data, err = yaml.Marshal(cassette)
+	} else {
+		data, err = json.MarshalIndent(cassette, "", "  ")
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "failed to encode cassette")
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to create cassette dir")
+		return
+	}
+
+	err = os.WriteFile(path, data, 0o644) //nolint: gosec
+	err = errors.Wrapf(err, "failed to write cassette: %s", path)
+	return
+}
+
+// NewBody creates a Body from data.
+func NewBody(data []byte) Body {
+
+	if utf8.Valid(data) {
+		return Body{Text: string(data)}
+	}
+
+	return Body{Base64: base64.StdEncoding.EncodeToString(data)}
+}
+
+// Bytes returns the body's data.
+func (body Body) Bytes() (data []byte, err error) {
+
+	if body.Base64 == "" {
+		data = []byte(body.Text)
+		return
+	}
+
+	data, err = base64.StdEncoding.DecodeString(body.Base64)
+	err = errors.Wrapf(err, "failed to decode body")
+	return
+}
+
+// unexported
+
+func isYaml(path string) bool {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
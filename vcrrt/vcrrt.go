@@ -0,0 +1,288 @@
+// Package vcrrt implements the Tripper interface, recording interactions to a cassette file and replaying them.
+package vcrrt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Mode determines whether interactions are recorded, replayed or both.
+type Mode string
+
+const (
+	// Record sends requests upstream, recording each interaction to a fresh cassette.
+	Record Mode = "record"
+	// Replay serves requests from the cassette, erroring for those not found there.
+	Replay Mode = "replay"
+	// RecordMissing serves requests from the cassette, sending and recording those not found there.
+	RecordMissing Mode = "record-missing"
+	// Passthrough sends requests upstream, leaving the cassette alone.
+	Passthrough Mode = "passthrough"
+
+	redacted string = "--redacted--"
+)
+
+// VcrRt implements the Tripper interface.
+type VcrRt struct {
+	// Path is the cassette file, json or yaml per its extension.
+	Path string
+	// Mode is one of Record, Replay, RecordMissing or Passthrough.
+	Mode Mode
+	// MatchHeaders are request headers compared along with method and url.
+	MatchHeaders []string
+	// MatchBody compares request bodies as well.
+	MatchBody bool
+	// RedactHeaders are request and response headers whose values are redacted before writing.
+	RedactHeaders map[string]bool
+	// ScrubRequest optionally scrubs secrets from requests, such as an api key in the query.
+	// It's applied to live requests before matching as well as before writing, so that they match as recorded.
+	ScrubRequest func(request *Request)
+	// Redact optionally scrubs an interaction before writing only, such as secrets in response bodies.
+	// Requests changed here no longer match when replayed, use ScrubRequest for those.
+	Redact func(interaction *Interaction)
+
+	cassette *Cassette
+	played   map[*Interaction]bool
+	mu       sync.Mutex
+	next     http.RoundTripper
+}
+
+// New creates a VcrRt, loading the cassette unless recording afresh or passing through.
+// Authorization is always redacted.
+func New(path string, mode Mode, redactHeaders []string) (rt *VcrRt, err error) {
+
+	rt = &VcrRt{
+		Path:          path,
+		Mode:          mode,
+		RedactHeaders: map[string]bool{},
+		cassette:      &Cassette{},
+		played:        map[*Interaction]bool{},
+	}
+
+	for _, key := range append(redactHeaders, "Authorization") {
+		rt.RedactHeaders[http.CanonicalHeaderKey(key)] = true
+	}
+
+	switch mode {
+	case Record, Passthrough:
+		return
+	case Replay, RecordMissing:
+	default:
+		err = errors.Errorf("unknown vcr mode: %q", mode)
+		return
+	}
+
+	cassette, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) && mode == RecordMissing {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	rt.cassette = cassette
+	return
+}
+
+// Wrap sets the next round tripper, thereby wrapping it.
+func (rt *VcrRt) Wrap(next http.RoundTripper) {
+	rt.next = next
+}
+
+// RoundTrip replays or records per mode.
+// Matching interactions are replayed in the order recorded, with the last repeated.
+func (rt *VcrRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	if rt.Mode == Passthrough {
+		response, err = rt.next.RoundTrip(request)
+		return
+	}
+
+	body, err := readBody(&request.Body)
+	if err != nil {
+		return
+	}
+	recorded := rt.redactRequest(request, body)
+
+	if rt.Mode != Record {
+		interaction := rt.find(recorded)
+		if interaction != nil {
+			response, err = replay(interaction, request)
+			return
+		}
+
+		if rt.Mode == Replay {
+			err = errors.Errorf("no interaction recorded for %s %s in %s", request.Method, request.URL, rt.Path)
+			return
+		}
+	}
+
+	response, err = rt.next.RoundTrip(request)
+	if err != nil {
+		return
+	}
+
+	err = rt.record(recorded, response)
+	if err != nil {
+		response.Body.Close()
+		response = nil
+	}
+	return
+}
+
+// unexported
+
+func (rt *VcrRt) find(recorded Request) (found *Interaction) {
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	for _, interaction := range rt.cassette.Interactions {
+		if !rt.match(interaction.Request, recorded) {
+			continue
+		}
+
+		found = interaction
+		if !rt.played[interaction] {
+			break
+		}
+	}
+
+	if found != nil {
+		rt.played[found] = true
+	}
+
+	return
+}
+
+func (rt *VcrRt) match(recorded, live Request) bool {
+
+	if recorded.Method != live.Method || recorded.Url != live.Url {
+		return false
+	}
+
+	for _, key := range rt.MatchHeaders {
+		if !slices.Equal(recorded.Header.Values(key), live.Header.Values(key)) {
+			return false
+		}
+	}
+
+	return !rt.MatchBody || recorded.Body == live.Body
+}
+
+// record buffers the response body, adding the interaction to the cassette and saving it.
+
+func (rt *VcrRt) record(recorded Request, response *http.Response) (err error) {
+
+	body, err := readBody(&response.Body)
+	if err != nil {
+		return
+	}
+
+	interaction := &Interaction{
+		Request: recorded,
+		Response: Response{
+			Status: response.StatusCode,
+			Header: rt.redactHeader(response.Header),
+			Body:   NewBody(body),
+		},
+	}
+
+	if rt.Redact != nil {
+		rt.Redact(interaction)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.cassette.Interactions = append(rt.cassette.Interactions, interaction)
+	rt.played[interaction] = true
+
+	err = rt.cassette.Save(rt.Path)
+	return
+}
+
+// redactRequest returns a request as recorded, with headers redacted and scrubbed.
+// Live requests are matched in this form so that redacted values compare equal.
+
+func (rt *VcrRt) redactRequest(request *http.Request, body []byte) (recorded Request) {
+
+	recorded = Request{
+		Method: request.Method,
+		Url:    request.URL.String(),
+		Header: rt.redactHeader(request.Header),
+		Body:   NewBody(body),
+	}
+
+	if rt.ScrubRequest != nil {
+		rt.ScrubRequest(&recorded)
+	}
+
+	return
+}
+
+func (rt *VcrRt) redactHeader(header http.Header) (redactedHeader http.Header) {
+
+	redactedHeader = header.Clone()
+	for key := range redactedHeader {
+		if rt.RedactHeaders[key] {
+			redactedHeader[key] = []string{redacted}
+		}
+	}
+
+	return
+}
+
+func replay(interaction *Interaction, request *http.Request) (response *http.Response, err error) {
+
+	body, err := interaction.Response.Body.Bytes()
+	if err != nil {
+		return
+	}
+
+	status := interaction.Response.Status
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	response = &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+	return
+}
+
+// readBody reads and replaces a body so it can be read again.
+
+func readBody(body *io.ReadCloser) (data []byte, err error) {
+
+	if *body == nil || *body == http.NoBody {
+		return
+	}
+
+	data, err = io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		err = errors.Wrapf(err, "failed to read body")
+		return
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+	return
+}
@@ -0,0 +1,315 @@
+package vcrrt
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestVcrRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VcrRt Suite")
+}
+
+var _ = Describe("VcrRt", func() {
+
+	Describe("tripperware", func() {
+
+		var (
+			rt   *VcrRt
+			trt  *testRt
+			path string
+			mode Mode
+			err  error
+		)
+
+		roundTrip := func(method, uri, body string) (data string, err error) {
+
+			var reader io.Reader
+			if body != "" {
+				reader = strings.NewReader(body)
+			}
+
+			request, err := http.NewRequest(method, uri, reader)
+			Expect(err).ToNot(HaveOccurred())
+			request.Header.Set("Authorization", "Bearer sekret")
+			request.Header.Set("Accept", "application/json")
+
+			response, err := rt.RoundTrip(request)
+			if err != nil {
+				return
+			}
+			defer response.Body.Close()
+
+			buf, err := io.ReadAll(response.Body)
+			data = fmt.Sprintf("%d %s", response.StatusCode, buf)
+			return
+		}
+
+		BeforeEach(func() {
+			trt = &testRt{}
+			path = filepath.Join(GinkgoT().TempDir(), "cassettes", "boxes.yaml")
+			mode = Record
+		})
+
+		JustBeforeEach(func() {
+			rt, err = New(path, mode, []string{"Set-Cookie"})
+			Expect(err).ToNot(HaveOccurred())
+			rt.Wrap(trt)
+		})
+
+		Describe("recording", func() {
+
+			It("saves interactions with secrets redacted", func() {
+				data, err := roundTrip("POST", "https://boxworld.org/boxes", `{"material": "wood"}`)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(`200 {"calls": 1}`))
+
+				cassette, err := Load(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cassette.Interactions).To(HaveLen(1))
+
+				interaction := cassette.Interactions[0]
+				Expect(interaction.Request.Url).To(Equal("https://boxworld.org/boxes"))
+				Expect(interaction.Request.Header.Get("Authorization")).To(Equal("--redacted--"))
+				Expect(interaction.Request.Body.Text).To(Equal(`{"material": "wood"}`))
+				Expect(interaction.Response.Header.Get("Set-Cookie")).To(Equal("--redacted--"))
+				Expect(interaction.Response.Body.Text).To(Equal(`{"calls": 1}`))
+			})
+
+			When("a redact func is given", func() {
+				JustBeforeEach(func() {
+					rt.Redact = func(interaction *Interaction) {
+						interaction.Response.Body = NewBody([]byte(`{"calls": "scrubbed"}`))
+					}
+				})
+
+				It("applies it before writing", func() {
+					_, err := roundTrip("GET", "https://boxworld.org/boxes", "")
+					Expect(err).ToNot(HaveOccurred())
+
+					cassette, err := Load(path)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cassette.Interactions[0].Response.Body.Text).To(Equal(`{"calls": "scrubbed"}`))
+				})
+			})
+		})
+
+		Describe("failing to save", func() {
+
+			BeforeEach(func() {
+				// a file where the cassette's directory would be
+				dir := GinkgoT().TempDir()
+				Expect(os.WriteFile(filepath.Join(dir, "cassettes"), nil, 0o600)).To(Succeed())
+				path = filepath.Join(dir, "cassettes", "boxes.yaml")
+			})
+
+			It("returns an error without a response", func() {
+				request, err := http.NewRequest("GET", "https://boxworld.org/boxes", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				response, err := rt.RoundTrip(request)
+				Expect(err).To(HaveOccurred())
+				Expect(response).To(BeNil())
+			})
+		})
+
+		Describe("replaying", func() {
+
+			BeforeEach(func() {
+				path = filepath.Join(GinkgoT().TempDir(), "boxes.json")
+
+				recorder, err := New(path, Record, nil)
+				Expect(err).ToNot(HaveOccurred())
+				recorder.Wrap(trt)
+				rt = recorder
+
+				_, err = roundTrip("GET", "https://boxworld.org/boxes", "")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = roundTrip("GET", "https://boxworld.org/boxes", "")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = roundTrip("POST", "https://boxworld.org/boxes", `{"material": "wood"}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				trt.Calls = 0
+				mode = Replay
+			})
+
+			It("serves interactions in order, repeating the last", func() {
+				data, err := roundTrip("GET", "https://boxworld.org/boxes", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(`200 {"calls": 1}`))
+
+				for range 2 {
+					data, err = roundTrip("GET", "https://boxworld.org/boxes", "")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal(`200 {"calls": 2}`))
+				}
+
+				Expect(trt.Calls).To(BeZero())
+			})
+
+			It("errors when nothing matches", func() {
+				_, err := roundTrip("GET", "https://boxworld.org/boxes?page=2", "")
+				Expect(err).To(MatchError(ContainSubstring("no interaction recorded for GET https://boxworld.org/boxes?page=2")))
+			})
+
+			When("matching on body", func() {
+				JustBeforeEach(func() {
+					rt.MatchBody = true
+				})
+
+				It("errors when body differs", func() {
+					data, err := roundTrip("POST", "https://boxworld.org/boxes", `{"material": "wood"}`)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal(`200 {"calls": 3}`))
+
+					_, err = roundTrip("POST", "https://boxworld.org/boxes", `{"material": "steel"}`)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			When("matching on headers", func() {
+				JustBeforeEach(func() {
+					rt.MatchHeaders = []string{"Accept", "Authorization"}
+				})
+
+				It("compares redacted headers as redacted", func() {
+					_, err := roundTrip("GET", "https://boxworld.org/boxes", "")
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
+			When("recording missing interactions", func() {
+				BeforeEach(func() {
+					mode = RecordMissing
+				})
+
+				It("sends and records only those not found", func() {
+					_, err := roundTrip("GET", "https://boxworld.org/boxes", "")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trt.Calls).To(BeZero())
+
+					data, err := roundTrip("DELETE", "https://boxworld.org/boxes/1", "")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal(`200 {"calls": 1}`))
+
+					cassette, err := Load(path)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cassette.Interactions).To(HaveLen(4))
+				})
+			})
+		})
+
+		Describe("scrubbing requests", func() {
+
+			BeforeEach(func() {
+				path = filepath.Join(GinkgoT().TempDir(), "keyed.json")
+			})
+
+			JustBeforeEach(func() {
+				rt.ScrubRequest = scrubKey
+			})
+
+			It("replays what it recorded with a secret in the query", func() {
+				_, err := roundTrip("GET", "https://boxworld.org/d?key=secret&size=3", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				cassette, err := Load(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cassette.Interactions[0].Request.Url).To(Equal("https://boxworld.org/d?key=--redacted--&size=3"))
+
+				rt, err = New(path, Replay, nil)
+				Expect(err).ToNot(HaveOccurred())
+				rt.ScrubRequest = scrubKey
+				rt.Wrap(trt)
+
+				data, err := roundTrip("GET", "https://boxworld.org/d?key=other-secret&size=3", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(`200 {"calls": 1}`))
+				Expect(trt.Calls).To(Equal(1))
+			})
+		})
+
+		Describe("passing through", func() {
+
+			BeforeEach(func() {
+				mode = Passthrough
+			})
+
+			It("leaves the cassette alone", func() {
+				_, err := roundTrip("GET", "https://boxworld.org/boxes", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(trt.Calls).To(Equal(1))
+
+				_, err = os.Stat(path)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("creating", func() {
+
+		It("errors when a cassette to replay is missing", func() {
+			_, err := New("nope.json", Replay, nil)
+			Expect(err).To(MatchError(ContainSubstring("failed to read cassette")))
+		})
+
+		It("errors on an unknown mode", func() {
+			_, err := New("nope.json", "rewind", nil)
+			Expect(err).To(MatchError(`unknown vcr mode: "rewind"`))
+		})
+	})
+
+	Describe("recording bodies", func() {
+
+		It("encodes binary data as base64", func() {
+			data := []byte{0xff, 0xfe, 0x00}
+
+			body := NewBody(data)
+			Expect(body.Base64).To(Equal("//4A"))
+
+			decoded, err := body.Bytes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded).To(Equal(data))
+		})
+	})
+})
+
+func scrubKey(request *Request) {
+
+	uri, err := url.Parse(request.Url)
+	Expect(err).ToNot(HaveOccurred())
+
+	query := uri.Query()
+	query.Set("key", "--redacted--")
+	uri.RawQuery = query.Encode()
+
+	request.Url = uri.String()
+}
+
+type testRt struct {
+	Calls int
+}
+
+func (rt *testRt) RoundTrip(request *http.Request) (response *http.Response, err error) {
+
+	rt.Calls++
+
+	response = &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Set-Cookie": {"session=sekret"}},
+		Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"calls": %d}`, rt.Calls))),
+		Request:    request,
+	}
+
+	return
+}